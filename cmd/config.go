@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/service"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage vg settings",
+	// Long is completed with the list of settings in init
+	Long: `Manage vg settings.
+
+Settings are stored in config.toml, ~/.vg/config.toml by default; 'vg config
+list' shows its path. Each setting can be overridden by an environment
+variable:
+
+%s
+With gomodcache = "per-version" modules are kept in GOPATH/pkg/mod, so each
+version and virtual environment downloads its own. With download.checksum =
+"warn" archives not matching their checksum are installed after a warning;
+"off" skips verification.
+
+  vg config set mirror https://golang.google.cn/dl/ https://go.dev/dl/
+  vg config set auto_install always
+  vg config get download.checksum
+  vg config list`,
+}
+
+// lookupKey returns the setting named by a command line argument.
+func lookupKey(name string) (*config.Key, error) {
+	k, err := config.LookupKey(name)
+	if err != nil {
+		return nil, withHint(&service.UsageError{Err: err}, "Run 'vg config list' to see the available keys")
+	}
+	return k, nil
+}
+
+// completeKeys completes the first argument with the setting names.
+func completeKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(config.Keys))
+	for _, k := range config.Keys {
+		names = append(names, k.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// warnEnvOverride warns if the environment variable of k hides the
+// configuration file.
+func warnEnvOverride(k *config.Key) {
+	if os.Getenv(k.EnvVar) != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %s is set and overrides this setting\n", k.EnvVar)
+	}
+}
+
+// keysHelp returns a table of the settings for the help text.
+func keysHelp() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, k := range config.Keys {
+		usage := k.Usage
+		if len(k.Choices) > 0 {
+			usage += ": " + strings.Join(k.Choices, ", ")
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", k.Name, k.EnvVar, usage)
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+
+	configCmd.Long = fmt.Sprintf(configCmd.Long, keysHelp())
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open config.toml in an editor",
+	Long: `Open config.toml in $VISUAL or $EDITOR, falling back to vi (notepad on
+Windows). A missing file is created with all settings commented out. The
+file is checked once the editor exits.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.GetConfigFile()
+		if err != nil {
+			return fmt.Errorf("error getting config file: %w", err)
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("error creating config directory: %w", err)
+			}
+			if err := os.WriteFile(path, []byte(configTemplate()), 0644); err != nil {
+				return fmt.Errorf("error creating config file: %w", err)
+			}
+		}
+
+		editor := strings.Fields(os.Getenv("VISUAL"))
+		if len(editor) == 0 {
+			editor = strings.Fields(os.Getenv("EDITOR"))
+		}
+		if len(editor) == 0 {
+			editor = []string{"vi"}
+			if runtime.GOOS == "windows" {
+				editor = []string{"notepad"}
+			}
+		}
+		child := exec.Command(editor[0], append(editor[1:], path)...)
+		child.Stdin = os.Stdin
+		child.Stdout = os.Stdout
+		child.Stderr = os.Stderr
+		if err := child.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return fmt.Errorf("editor %s exited with code %d", editor[0], exitErr.ExitCode())
+			}
+			return withHint(fmt.Errorf("error starting editor: %w", err), "Set VISUAL or EDITOR to your editor")
+		}
+
+		if _, err := config.Load(); err != nil {
+			return withHint(err, "Run 'vg config edit' to fix it")
+		}
+		return nil
+	},
+}
+
+// configTemplate returns the content of a new configuration file, listing
+// every setting commented out.
+func configTemplate() string {
+	var b strings.Builder
+	b.WriteString("# vg settings. Environment variables override them; see 'vg config --help'.\n")
+	table := ""
+	for _, k := range config.Keys {
+		t, name := k.Table()
+		if t != table {
+			fmt.Fprintf(&b, "\n[%s]\n", t)
+			table = t
+		}
+		fmt.Fprintf(&b, "\n# %s (%s)\n", k.Usage, k.EnvVar)
+		value := "\"\""
+		if k.Default != "" {
+			value = config.FormatValue(k, []string{k.Default})
+		}
+		fmt.Fprintf(&b, "# %s = %s\n", name, value)
+	}
+	return b.String()
+}
+
+func init() {
+	configCmd.AddCommand(configEditCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fun7257/vg/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Long: `Print the effective value of a setting: its environment variable, then
+config.toml, then the default. Lists are printed comma-separated. Nothing is
+printed for an unset setting without a default.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k, err := lookupKey(args[0])
+		if err != nil {
+			return err
+		}
+		settings, err := config.LoadSettings()
+		if err != nil {
+			return err
+		}
+		if v := settings.Lookup(k); len(v.Values) > 0 {
+			fmt.Println(v)
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+}
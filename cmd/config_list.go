@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all settings with their effective values",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+		path, err := config.GetConfigFile()
+		if err != nil {
+			return fmt.Errorf("error getting config file: %w", err)
+		}
+		settings, err := config.LoadSettings()
+		if err != nil {
+			return err
+		}
+
+		doc := output.ConfigList{
+			Header:   output.NewHeader(output.KindConfigList),
+			File:     path,
+			Settings: make([]output.Setting, 0, len(config.Keys)),
+		}
+		values := make([]config.Value, 0, len(config.Keys))
+		for _, k := range config.Keys {
+			v := settings.Lookup(k)
+			values = append(values, v)
+			s := output.Setting{Key: k.Name, Source: string(v.Origin), EnvVar: k.EnvVar}
+			switch {
+			case k.Kind == config.KindList && len(v.Values) > 0:
+				s.Value = v.Values
+			case len(v.Values) > 0:
+				s.Value = v.Values[0]
+			}
+			doc.Settings = append(doc.Settings, s)
+		}
+
+		if format != output.Table {
+			return writeOutput(format, doc)
+		}
+
+		width := 0
+		for _, k := range config.Keys {
+			width = max(width, len(k.Name))
+		}
+		fmt.Printf("Settings (%s):\n", path)
+		for _, v := range values {
+			var note string
+			switch {
+			case len(v.Values) == 0:
+				fmt.Printf("  %-*s   (unset)\n", width, v.Key.Name)
+				continue
+			case v.Origin == config.OriginDefault:
+				note = "  (default)"
+			case v.Origin == config.OriginEnv:
+				note = fmt.Sprintf("  (from %s)", v.Key.EnvVar)
+			}
+			fmt.Printf("  %-*s = %s%s\n", width, v.Key.Name, v, note)
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configListCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/service"
+
+	"github.com/spf13/cobra"
+)
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Set a setting in config.toml",
+	Long: `Set a setting in config.toml, creating the file if needed. List settings
+such as mirror take several values, as arguments or comma-separated.`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k, err := lookupKey(args[0])
+		if err != nil {
+			return err
+		}
+
+		var values []string
+		for _, arg := range args[1:] {
+			if k.Kind != config.KindList {
+				values = append(values, arg)
+				continue
+			}
+			for _, v := range strings.Split(arg, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+		if len(values) == 0 || k.Kind != config.KindList && len(values) > 1 {
+			return &service.UsageError{Err: fmt.Errorf("%s takes a single value", k.Name)}
+		}
+		for _, v := range values {
+			if err := k.Validate(v); err != nil {
+				return &service.UsageError{Err: err}
+			}
+		}
+
+		if err := config.SetSetting(k, values); err != nil {
+			return fmt.Errorf("error writing config file: %w", err)
+		}
+		fmt.Printf("✅ Set %s = %s\n", k.Name, strings.Join(values, ","))
+		warnEnvOverride(k)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+}
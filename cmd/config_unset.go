@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fun7257/vg/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key>",
+	Short:             "Remove a setting from config.toml",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k, err := lookupKey(args[0])
+		if err != nil {
+			return err
+		}
+		removed, err := config.UnsetSetting(k)
+		if err != nil {
+			return fmt.Errorf("error writing config file: %w", err)
+		}
+		if !removed {
+			fmt.Printf("%s is not set\n", k.Name)
+			return nil
+		}
+		fmt.Printf("✅ Unset %s\n", k.Name)
+		warnEnvOverride(k)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configUnsetCmd)
+}
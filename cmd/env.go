@@ -19,4 +19,5 @@ func init() {
 
 	// Flags
 	newCmd.Flags().StringP("message", "m", "", "Add a remark to the environment")
+	loadCmd.Flags().Bool("session", false, "Load the environment in the current shell only")
 }
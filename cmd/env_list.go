@@ -7,6 +7,8 @@ import (
 	"text/tabwriter"
 
 	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/output"
+	"github.com/fun7257/vg/internal/service"
 
 	"github.com/spf13/cobra"
 )
@@ -14,41 +16,52 @@ import (
 var listEnvCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List virtual environments for the current Go version",
-	Run: func(cmd *cobra.Command, args []string) {
-		// 1. Get current Go version
-		currentLink, err := config.GetCurrentLink()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat()
 		if err != nil {
-			fmt.Printf("Error getting current link: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 
-		target, err := os.Readlink(currentLink)
+		// 1. Get current Go version
+		currentVersion, err := service.CurrentVersion()
 		if err != nil {
-			fmt.Printf("❌ No Go version is currently active\n")
-			os.Exit(1)
+			return err
 		}
-		currentVersion := filepath.Base(target)
 
 		// 2. Get envs dir for this version
 		// Since config.GetEnvDir takes (version, name), we need to manually list directory
 		envsRoot, err := config.GetEnvsDir()
 		if err != nil {
-			fmt.Printf("Error getting envs dir: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting envs dir: %w", err)
 		}
 		versionEnvsDir := filepath.Join(envsRoot, currentVersion)
 
+		if format != output.Table {
+			activeName := ""
+			if active, err := activeVersion(); err == nil && active == currentVersion {
+				activeName = activeEnv()
+			}
+			envs, err := versionEnvs(currentVersion, activeName)
+			if err != nil {
+				return fmt.Errorf("error reading envs directory: %w", err)
+			}
+			return writeOutput(format, output.EnvList{
+				Header:  output.NewHeader(output.KindEnvList),
+				Version: currentVersion,
+				Envs:    envs,
+			})
+		}
+
 		fmt.Printf("Virtual environments for Go %s:\n\n", currentVersion)
 
 		if _, err := os.Stat(versionEnvsDir); os.IsNotExist(err) {
 			fmt.Println("  (none)")
-			return
+			return nil
 		}
 
 		entries, err := os.ReadDir(versionEnvsDir)
 		if err != nil {
-			fmt.Printf("Error reading envs directory: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error reading envs directory: %w", err)
 		}
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
@@ -75,6 +88,7 @@ var listEnvCmd = &cobra.Command{
 		if count == 0 {
 			fmt.Println("  (none)")
 		}
+		return nil
 	},
 }
 
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
 
 	"github.com/spf13/cobra"
 )
@@ -13,93 +15,65 @@ import (
 var loadCmd = &cobra.Command{
 	Use:   "load [env_name]",
 	Short: "Load a virtual environment for the current Go version",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		envName := args[0]
+	Long: `Load a virtual environment for the current Go version.
 
-		sdksDir, err := config.GetSdksDir()
-		if err != nil {
-			fmt.Printf("Error getting sdks dir: %v\n", err)
-			os.Exit(1)
-		}
+By default the global 'current' symlinks are switched, affecting every shell.
+With --session only the current shell is switched (requires the shell
+function installed by 'vg init').`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envName := args[0]
 
-		vgHome, err := config.GetVgHome()
-		if err != nil {
-			fmt.Printf("Error getting vg home: %v\n", err)
-			os.Exit(1)
+		session, _ := cmd.Flags().GetBool("session")
+		if session {
+			currentVersion, err := activeVersion()
+			if err != nil {
+				return err
+			}
+			env, err := virtualGoEnv(currentVersion, envName)
+			if err != nil {
+				return err
+			}
+			if err := printSessionExports(currentVersion, envName, env); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "✅ Loaded environment '%s' (Go %s) in this shell\n", envName, currentVersion)
+			return nil
 		}
 
-		// 1. Get current Go version
-		currentLink, err := config.GetCurrentLink()
+		sdksDir, err := config.GetSdksDir()
 		if err != nil {
-			fmt.Printf("Error getting current link: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting sdks dir: %w", err)
 		}
 
-		target, err := os.Readlink(currentLink)
+		// Serialize with other commands switching symlinks
+		activationLock, err := acquireLock(lock.Activation)
 		if err != nil {
-			fmt.Printf("❌ No Go version is currently active\n")
-			fmt.Printf("Please run 'vg use <version>' first\n")
-			os.Exit(1)
+			return err
 		}
-		currentVersion := filepath.Base(target)
+		defer func() {
+			_ = activationLock.Release()
+		}()
 
-		// 2. Find environment in current version
-		envDir, err := config.GetEnvDir(currentVersion, envName)
+		// 1. Get current Go version
+		currentVersion, err := service.CurrentVersion()
 		if err != nil {
-			fmt.Printf("Error getting env path: %v\n", err)
-			os.Exit(1)
+			return withHint(err, "Please run 'vg use <version>' first")
 		}
 
-		if _, err := os.Stat(envDir); os.IsNotExist(err) {
-			fmt.Printf("❌ Environment '%s' not found for Go %s\n", envName, currentVersion)
-			fmt.Printf("Run 'vg env new %s' to create it\n", envName)
-			os.Exit(1)
-		}
-
-		// Verify SDK exists (sanity check, it should matched currentVersion which is active)
-		versionPath := filepath.Join(sdksDir, currentVersion)
-		if _, err := os.Stat(versionPath); os.IsNotExist(err) {
-			fmt.Printf("❌ Critical Error: Current SDK %s is missing?\n", currentVersion)
-			os.Exit(1)
-		}
-
-		// Prepare paths
-		targetGopath := filepath.Join(envDir, "gopath")
-		targetGoenv := filepath.Join(envDir, "goenv")
-		targetGocache := filepath.Join(envDir, "gocache")
-
-		// Helper function to update a symlink
-		updateSymlink := func(linkPath, targetPath string, linkName string) error {
-			if _, err := os.Lstat(linkPath); err == nil {
-				if err := os.Remove(linkPath); err != nil {
-					return fmt.Errorf("error removing old %s symlink: %w", linkName, err)
-				}
-			}
-			if err := os.Symlink(targetPath, linkPath); err != nil {
-				return fmt.Errorf("error creating %s symlink: %w", linkName, err)
+		// 2. Switch symlinks to the environment of the current version
+		if err := service.LoadEnv(sdksDir, currentVersion, envName); err != nil {
+			var notInstalledErr *service.NotInstalledError
+			if errors.As(err, &notInstalledErr) && notInstalledErr.Env != "" {
+				return withHint(err, "Run 'vg env new %s' to create it", envName)
 			}
-			return nil
+			return err
 		}
 
-		// Update symlinks (NOTE: 'current' link to SDK does NOT change, as we are staying on currentVersion)
-
-		if err := updateSymlink(filepath.Join(vgHome, "current-gopath"), targetGopath, "current-gopath"); err != nil {
-			fmt.Printf("❌ %v\n", err)
-			os.Exit(1)
-		}
-
-		if err := updateSymlink(filepath.Join(vgHome, "current-gocache"), targetGocache, "current-gocache"); err != nil {
-			fmt.Printf("❌ %v\n", err)
-			os.Exit(1)
-		}
-
-		if err := updateSymlink(filepath.Join(vgHome, "current-goenv"), targetGoenv, "current-goenv"); err != nil {
-			fmt.Printf("❌ %v\n", err)
-			os.Exit(1)
-		}
+		refreshShims()
 
 		fmt.Printf("✅ Loaded environment '%s' (Go %s)\n", envName, currentVersion)
 		fmt.Println("\nEnvironment variables will be updated automatically via symlinks.")
+		return nil
 	},
 }
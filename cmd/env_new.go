@@ -5,7 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
 
 	"github.com/spf13/cobra"
 )
@@ -14,82 +15,40 @@ var newCmd = &cobra.Command{
 	Use:   "new [name]",
 	Short: "Create a new virtual environment",
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		envName := args[0]
 
-		// 1. Get current Go version
-		currentLink, err := config.GetCurrentLink()
+		// Serialize with other commands changing environments
+		activationLock, err := acquireLock(lock.Activation)
 		if err != nil {
-			fmt.Printf("Error getting current link: %v\n", err)
-			os.Exit(1)
+			return err
 		}
+		defer func() {
+			_ = activationLock.Release()
+		}()
 
-		target, err := os.Readlink(currentLink)
+		// 1. Get current Go version
+		currentVersion, err := service.CurrentVersion()
 		if err != nil {
-			fmt.Printf("❌ No Go version is currently active\n")
-			fmt.Printf("Please run 'vg use <version>' first\n")
-			os.Exit(1)
+			return withHint(err, "Please run 'vg use <version>' first")
 		}
-		currentVersion := filepath.Base(target)
 
-		// 2. Check if env already exists
-		envDir, err := config.GetEnvDir(currentVersion, envName)
+		// 2. Create env directory structure (envs/<version>/<name>)
+		envDir, err := service.NewEnv(currentVersion, envName)
 		if err != nil {
-			fmt.Printf("Error getting env dir: %v\n", err)
-			os.Exit(1)
-		}
-
-		// Check if it exists for this version
-		if _, err := os.Stat(envDir); err == nil {
-			fmt.Printf("❌ Environment '%s' already exists for Go %s\n", envName, currentVersion)
-			os.Exit(1)
-		}
-
-		fmt.Printf("Creating virtual environment '%s' using Go %s...\n", envName, currentVersion)
-
-		// 3. Create env directory structure (envs/<version>/<name>)
-		if err := os.MkdirAll(envDir, 0755); err != nil {
-			fmt.Printf("Error creating env directory: %v\n", err)
-			os.Exit(1)
-		}
-
-		// Create GOPATH
-		gopath := filepath.Join(envDir, "gopath")
-		if err := os.MkdirAll(gopath, 0755); err != nil {
-			fmt.Printf("Error creating gopath: %v\n", err)
-			os.Exit(1)
-		}
-		for _, subdir := range []string{"src", "bin", "pkg"} {
-			if err := os.MkdirAll(filepath.Join(gopath, subdir), 0755); err != nil {
-				fmt.Printf("Error creating gopath subdirectory %s: %v\n", subdir, err)
-				os.Exit(1)
-			}
-		}
-
-		// Create GOCACHE
-		gocache := filepath.Join(envDir, "gocache")
-		if err := os.MkdirAll(gocache, 0755); err != nil {
-			fmt.Printf("Error creating gocache: %v\n", err)
-			os.Exit(1)
-		}
-
-		// Create GOENV
-		goenvPath := filepath.Join(envDir, "goenv")
-		goenvContent := fmt.Sprintf("# Environment '%s' (Go %s)\n# Managed by vg.\n", envName, currentVersion)
-		if err := os.WriteFile(goenvPath, []byte(goenvContent), 0644); err != nil {
-			fmt.Printf("Error creating goenv file: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 
 		// Save remark if provided
 		remark, _ := cmd.Flags().GetString("message")
 		if remark != "" {
 			if err := os.WriteFile(filepath.Join(envDir, "remark.txt"), []byte(remark), 0644); err != nil {
-				fmt.Printf("⚠️  Warning: Failed to save remark: %v\n", err)
+				fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to save remark: %v\n", err)
 			}
 		}
 
-		fmt.Printf("✅ Created virtual environment '%s'\n", envName)
+		fmt.Printf("✅ Created virtual environment '%s' using Go %s\n", envName, currentVersion)
 		fmt.Printf("\nActivate it with:\n  vg env load %s\n", envName)
+		return nil
 	},
 }
@@ -1,11 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 
-	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
 
 	"github.com/spf13/cobra"
 )
@@ -14,61 +14,35 @@ var rmEnvCmd = &cobra.Command{
 	Use:   "rm [name]",
 	Short: "Remove a virtual environment",
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		envName := args[0]
 
-		// 1. Get current Go version
-		currentLink, err := config.GetCurrentLink()
-		if err != nil {
-			fmt.Printf("Error getting current link: %v\n", err)
-			os.Exit(1)
-		}
-
-		target, err := os.Readlink(currentLink)
+		// Serialize with other commands changing environments
+		activationLock, err := acquireLock(lock.Activation)
 		if err != nil {
-			fmt.Printf("❌ No Go version is currently active\n")
-			os.Exit(1)
+			return err
 		}
-		currentVersion := filepath.Base(target)
+		defer func() {
+			_ = activationLock.Release()
+		}()
 
-		// 2. Resolve Environment Path
-		envDir, err := config.GetEnvDir(currentVersion, envName)
+		// 1. Get current Go version
+		currentVersion, err := service.CurrentVersion()
 		if err != nil {
-			fmt.Printf("Error getting env dir: %v\n", err)
-			os.Exit(1)
-		}
-
-		// 3. Check existence
-		if _, err := os.Stat(envDir); os.IsNotExist(err) {
-			fmt.Printf("❌ Environment '%s' does not exist for Go %s\n", envName, currentVersion)
-			os.Exit(1)
-		}
-
-		// 4. Check if currently active (safeguard)
-		// We check if the current GOPATH matches this environment
-		currentGopathLink, _ := config.GetCurrentGopathLink()
-		targetGopath, _ := os.Readlink(currentGopathLink)
-
-		// Ensure absolute paths for comparison
-		absEnvDir, _ := filepath.Abs(envDir)
-		absTargetGopath, _ := filepath.Abs(targetGopath)
-		// The active environment root is the parent of the GOPATH (as created by vg new)
-		activeEnvDir := filepath.Dir(absTargetGopath)
-
-		if activeEnvDir == absEnvDir {
-			fmt.Printf("❌ Cannot remove active environment '%s'\n", envName)
-			fmt.Println("Please run 'vg env exit' first.")
-			os.Exit(1)
+			return err
 		}
 
-		// 5. Remove
-		fmt.Printf("Removing environment '%s' (Go %s)...\n", envName, currentVersion)
-		if err := os.RemoveAll(envDir); err != nil {
-			fmt.Printf("Error removing environment: %v\n", err)
-			os.Exit(1)
+		// 2. Remove unless it is active
+		if err := service.RemoveEnv(currentVersion, envName); err != nil {
+			var inUseErr *service.InUseError
+			if errors.As(err, &inUseErr) {
+				return withHint(err, "Please run 'vg env exit' first.")
+			}
+			return err
 		}
 
-		fmt.Printf("✅ Removed environment '%s'\n", envName)
+		fmt.Printf("✅ Removed environment '%s' (Go %s)\n", envName, currentVersion)
+		return nil
 	},
 }
 
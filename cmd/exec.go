@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
+
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <version|env> -- <command> [args...]",
+	Short: "Run a command under a specific Go version or virtual environment",
+	Long: `Run a single command under a specific Go version or virtual environment
+without switching globally.
+
+The first argument is either a Go version (or alias such as 'latest') or the
+name of a virtual environment of the active Go version. The command runs with
+GOROOT, GOPATH, GOCACHE, GOENV, GOMODCACHE and PATH set accordingly, and vg
+exits with its exit code, or 128 plus the signal number if a signal killed it.
+
+  vg exec 1.22.10 -- go test ./...
+  vg exec my-env -- go build`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := args[0]
+		command := args[1:]
+		if len(command) > 0 && command[0] == "--" {
+			command = command[1:]
+		}
+		if len(command) == 0 {
+			return &service.UsageError{Err: errors.New("no command given")}
+		}
+
+		install, _ := cmd.Flags().GetBool("install")
+
+		version, env, err := execGoEnv(target, install)
+		if err != nil {
+			return err
+		}
+
+		return runWithGoEnv(version, env, command, false)
+	},
+}
+
+// runWithGoEnv runs command with the Go environment of env. It returns a
+// service.ExitError carrying the command's exit code if it fails, 128 plus
+// the signal number if a signal killed it, or 127 if it cannot be started. With toolsOnly the command must be found in
+// GOROOT/bin or GOPATH/bin instead of anywhere on PATH.
+func runWithGoEnv(version string, env goEnv, command []string, toolsOnly bool) error {
+	prefix, path := sessionPath(env)
+	environ := append(os.Environ(),
+		"GOROOT="+env.Goroot,
+		"GOPATH="+env.Gopath,
+		"GOCACHE="+env.Gocache,
+		"GOENV="+env.Goenv,
+		"GOMODCACHE="+env.Gomodcache,
+		"PATH="+strings.Join(path, string(os.PathListSeparator)),
+		sessionVersionVar+"="+version,
+	)
+
+	// Resolve the command against the new PATH, not ours
+	name := command[0]
+	if !strings.ContainsRune(name, filepath.Separator) {
+		search := path
+		if toolsOnly {
+			search = prefix
+		}
+		found := false
+		for _, dir := range search {
+			if candidate := filepath.Join(dir, name); isExecutable(candidate) {
+				name = candidate
+				found = true
+				break
+			}
+		}
+		if !found && toolsOnly {
+			return &service.ExitError{Code: 127, Err: fmt.Errorf("%s not found in GOROOT/bin or GOPATH/bin of Go %s", name, version)}
+		}
+	}
+
+	child := exec.Command(name, command[1:]...)
+	child.Env = environ
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	// Outlive the child and forward signals to it. Ctrl-C already reaches
+	// the child through the terminal's process group, so SIGINT is caught
+	// but not forwarded; ignoring it instead would be inherited by the child.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		return &service.ExitError{Code: 127, Err: err}
+	}
+	go func() {
+		for sig := range signals {
+			if sig != os.Interrupt {
+				_ = child.Process.Signal(sig)
+			}
+		}
+	}()
+
+	if err := child.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code := exitErr.ExitCode()
+			// Killed by a signal: exit like a shell would
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+				code = 128 + int(status.Signal())
+			} else if code < 0 {
+				code = 1
+			}
+			// The command has reported its own failure
+			return &service.ExitError{Code: code}
+		}
+		return err
+	}
+	return nil
+}
+
+// execGoEnv resolves the target of 'vg exec': an installed version, a
+// virtual environment of the active version, or a version to install.
+func execGoEnv(target string, install bool) (string, goEnv, error) {
+	sdksDir, err := config.GetSdksDir()
+	if err != nil {
+		return "", goEnv{}, err
+	}
+
+	// Installed version
+	normalizedVersion := strings.TrimPrefix(target, "go")
+	if downloader.IsInstalled(sdksDir, normalizedVersion) {
+		env, err := versionGoEnv(normalizedVersion)
+		return normalizedVersion, env, err
+	}
+
+	// Virtual environment of the active version
+	if currentVersion, err := activeVersion(); err == nil {
+		if envDir, err := config.GetEnvDir(currentVersion, target); err == nil {
+			if _, err := os.Stat(envDir); err == nil {
+				env, err := virtualGoEnv(currentVersion, target)
+				return currentVersion, env, err
+			}
+		}
+	}
+
+	// Version alias or version that is not installed yet
+	normalizedVersion, err = downloader.ResolveVersion(target, sdksDir)
+	if err != nil {
+		return "", goEnv{}, err
+	}
+	if !downloader.IsInstalled(sdksDir, normalizedVersion) {
+		if !install {
+			return "", goEnv{}, withHint(&service.NotInstalledError{Version: normalizedVersion},
+				"'%s' is neither an installed Go version nor an environment of the active version.\nUse --install to install Go %s.", target, normalizedVersion)
+		}
+
+		// Another process may be installing the same version right now
+		sdkLock, err := acquireLock(lock.SDK(normalizedVersion))
+		if err != nil {
+			return "", goEnv{}, err
+		}
+		defer func() {
+			_ = sdkLock.Release()
+		}()
+
+		if !downloader.IsInstalled(sdksDir, normalizedVersion) {
+			distsDir, err := config.GetDistsDir()
+			if err != nil {
+				return "", goEnv{}, err
+			}
+			// Keep installer output away from the command's stdout
+			installer := downloader.NewInstaller()
+			installer.Log = os.Stderr
+			if err := service.Install(installer, normalizedVersion, distsDir, sdksDir); err != nil {
+				return "", goEnv{}, err
+			}
+		}
+	}
+	env, err := versionGoEnv(normalizedVersion)
+	return normalizedVersion, env, err
+}
+
+// isExecutable reports whether path is a regular file with an execute bit.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	// Everything after the target belongs to the command
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().Bool("install", false, "Install the Go version if it is not installed")
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/shell"
 
 	"github.com/spf13/cobra"
 )
@@ -17,68 +18,102 @@ var initCmd = &cobra.Command{
 Add the following to your shell profile (e.g., ~/.zshrc or ~/.bashrc):
 
   eval "$(vg init)"
+
+The shell is detected from $SHELL; use --shell to choose one explicitly:
+
+  bash, zsh   eval "$(vg init)"
+  fish        vg init --shell fish | source
+  nushell     vg init --shell nu | save -f ~/.cache/vg.nu
+              (then add 'source ~/.cache/vg.nu' to config.nu)
+  powershell  vg init --shell powershell | Out-String | Invoke-Expression
+  elvish      eval (vg init --shell elvish | slurp)
+
+Besides the environment variables, this installs a 'vg' shell function so
+that 'vg shell <version>' and 'vg env load --session' can switch the current
+shell without touching the global symlinks.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sh, err := initShell(cmd)
+		if err != nil {
+			return err
+		}
+
 		// Get symlink paths
 		currentLink, err := config.GetCurrentLink()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting current link: %v\n", err)
-			return
+			return fmt.Errorf("error getting current link: %w", err)
 		}
 
 		currentGopathLink, err := config.GetCurrentGopathLink()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting current-gopath link: %v\n", err)
-			return
+			return fmt.Errorf("error getting current-gopath link: %w", err)
 		}
 
 		currentGocacheLink, err := config.GetCurrentGocacheLink()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting current-gocache link: %v\n", err)
-			return
+			return fmt.Errorf("error getting current-gocache link: %w", err)
 		}
 
 		currentGoenvLink, err := config.GetCurrentGoenvLink()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting current-goenv link: %v\n", err)
-			return
+			return fmt.Errorf("error getting current-goenv link: %w", err)
 		}
 
+		// Shell function evaluating the output of session commands
+		fmt.Print(sh.Hook())
+		fmt.Print(sh.Render([]shell.Change{shell.Set(shell.EnvVar, sh.Name())}))
+
 		// Check if symlinks exist
 		if _, err := os.Lstat(currentLink); err != nil {
 			fmt.Printf("# vg: No Go version is currently active\n")
 			fmt.Printf("# Run 'vg use <version>' to activate a version\n")
-			return
+			return nil
 		}
 
-		// Get shared GOMODCACHE directory
-		gomodcache, err := config.GetGomodcacheDir()
+		// Shared GOMODCACHE directory, or the one in the current GOPATH
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		gomodcache, err := gomodcacheFor(cfg, currentGopathLink)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting gomodcache: %v\n", err)
-			return
+			return fmt.Errorf("error getting gomodcache: %w", err)
 		}
 
 		// Ensure GOMODCACHE directory exists
 		if err := os.MkdirAll(gomodcache, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating gomodcache directory: %v\n", err)
-			return
+			return fmt.Errorf("error creating gomodcache directory: %w", err)
 		}
 
 		// Set environment variables pointing to symlinks
 		// These symlinks are updated by 'vg use' command
-		fmt.Printf("export GOROOT=\"%s\"\n", currentLink)
-		fmt.Printf("export GOPATH=\"%s\"\n", currentGopathLink)
-		fmt.Printf("export GOCACHE=\"%s\"\n", currentGocacheLink)
-		fmt.Printf("export GOENV=\"%s\"\n", currentGoenvLink)
-		fmt.Printf("export GOMODCACHE=\"%s\"\n", gomodcache)
-
-		// Set PATH (add GOROOT/bin and GOPATH/bin)
-		currentBin := filepath.Join(currentLink, "bin")
-		gopathBin := filepath.Join(currentGopathLink, "bin")
-		fmt.Printf("export PATH=\"%s:%s:$PATH\"\n", currentBin, gopathBin)
+		// PATH gets GOROOT/bin and GOPATH/bin
+		fmt.Print(sh.Render([]shell.Change{
+			shell.Set("GOROOT", currentLink),
+			shell.Set("GOPATH", currentGopathLink),
+			shell.Set("GOCACHE", currentGocacheLink),
+			shell.Set("GOENV", currentGoenvLink),
+			shell.Set("GOMODCACHE", gomodcache),
+			shell.PrependPath([]string{
+				filepath.Join(currentLink, "bin"),
+				filepath.Join(currentGopathLink, "bin"),
+			}),
+		}))
+		return nil
 	},
 }
 
+// initShell returns the shell selected with --shell, or the login shell.
+func initShell(cmd *cobra.Command) (shell.Shell, error) {
+	name, _ := cmd.Flags().GetString("shell")
+	if name != "" {
+		return shell.Get(name)
+	}
+	return shell.Detect()
+}
+
 func init() {
 	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().String("shell", "", "Shell to generate code for (bash, zsh, fish, nu, powershell, elvish)")
 }
@@ -2,111 +2,217 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
+	"runtime"
 	"strings"
 
 	"github.com/fun7257/vg/internal/config"
 	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
 
 	"github.com/spf13/cobra"
 )
 
 var installCmd = &cobra.Command{
-	Use:   "install [version]",
-	Short: "Install a specific Go version",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		version := args[0]
+	Use:   "install <version>...",
+	Short: "Install one or more Go versions",
+	Long: `Install one or more Go versions.
 
-		// Normalize version (remove 'go' prefix if present)
-		normalizedVersion := strings.TrimPrefix(version, "go")
+Several versions are installed concurrently, at most --jobs at a time:
+
+  vg install 1.22.10 1.23.4 1.24.0
+
+The command fails if any of the versions could not be installed.
+
+The version may be exact (e.g. 1.25.4) or an alias:
+  latest, stable   the newest stable release
+  1.23             the newest patch release of Go 1.23
+  1.23rc           the newest release candidate of Go 1.23
+
+Archives are downloaded from https://go.dev/dl/ unless mirrors are set in
+VG_MIRROR (comma-separated) or in config.toml:
+
+  mirror = ["https://golang.google.cn/dl/", "https://go.dev/dl/"]
+
+Mirrors are tried in order; one answering 404 or 5xx falls back to the next.
+
+Interrupted downloads are kept as <archive>.part and resumed on the next
+attempt. Failed downloads are retried with exponential backoff, configured
+with VG_DOWNLOAD_RETRIES and VG_DOWNLOAD_RETRY_DELAY or in config.toml:
+
+  [download]
+  retries = 3
+  retry_delay = "1s"
+
+Where go.dev is unreachable but a module proxy is not, set
+VG_DOWNLOAD_SOURCE=proxy (or source = "proxy" under [download]) to fetch
+the golang.org/toolchain module from GOPROXY instead, as the go command does
+when switching toolchains. file:// proxies are supported. The module zip is
+verified against GOSUMDB, or against a go.sum named by VG_TOOLCHAIN_GOSUM
+(or gosum under [download]) that pins its hash.
+
+Archives not matching their checksum fail to install unless download.checksum
+is set to "warn" or "off". See 'vg config --help' for all settings.
+
+Use --from to install without network access, from a local archive or an
+existing GOROOT directory. The version is read from its VERSION file, and
+the platform from the archive name or its pkg/tool directory; SDKs for other
+platforms are stored like those installed with --os and --arch:
+
+  vg install --from ./go1.24.0.linux-amd64.tar.gz
+  vg install --from /opt/go
+
+An archive is verified against --sha256, the <archive>.sha256 file next to
+it, or the release index, in that order. A directory is copied as is.
+
+'vg install tip' builds the latest commit of the Go repository with
+make.bash, and --source builds a git ref (branch, tag, commit or change
+ref such as refs/changes/45/12345/3), the HEAD of a local git checkout, or
+a source tarball:
+
+  vg install tip
+  vg install --source release-branch.go1.24
+  vg install --source ~/src/go
+
+The newest installed release, or --bootstrap, is used as GOROOT_BOOTSTRAP.
+Builds from git are installed as tip-<commit>; 'tip' stands for the most
+recently built one in other commands, e.g. 'vg use tip'.
+
+--os and --arch install releases for another platform, e.g. to prepare
+toolchains for other machines. They are stored as <version>.<os>-<arch>
+(e.g. 1.24.0.linux-arm64) next to the native SDKs:
+
+  vg install --os linux --arch arm64 1.24.0
+  vg install --os windows --arch amd64 1.24.0`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("from")
+		source, _ := cmd.Flags().GetString("source")
+		if from != "" && source != "" {
+			return fmt.Errorf("--from and --source cannot be used together")
+		}
+		if from != "" || source != "" || (len(args) == 1 && args[0] == downloader.Tip) {
+			if cmd.Flags().Changed("os") || cmd.Flags().Changed("arch") {
+				return fmt.Errorf("--os and --arch only apply to downloaded releases")
+			}
+		}
+		if from != "" || source != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Progress goes to stderr, keeping stdout clean for wrapper scripts
+		stderr := cmd.ErrOrStderr()
 
 		distsDir, err := config.GetDistsDir()
 		if err != nil {
-			fmt.Printf("Error getting dists dir: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting dists dir: %w", err)
 		}
 
 		sdksDir, err := config.GetSdksDir()
 		if err != nil {
-			fmt.Printf("Error getting sdks dir: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting sdks dir: %w", err)
 		}
 
-		if _, err := os.Stat(filepath.Join(sdksDir, normalizedVersion)); err == nil {
-			fmt.Printf("❌ Go %s is already installed\n", normalizedVersion)
-			os.Exit(1)
+		platform, err := installPlatform(cmd)
+		if err != nil {
+			return &service.UsageError{Err: err}
 		}
 
-		fmt.Printf("Installing Go %s...\n", normalizedVersion)
-		// DownloadAndInstall handles both downloading (if needed) and extracting
-		// It will skip download if the archive already exists, but will always extract
-		if err := downloader.DownloadAndInstall(normalizedVersion, distsDir, sdksDir); err != nil {
-			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+		if from, _ := cmd.Flags().GetString("from"); from != "" {
+			sha256, _ := cmd.Flags().GetString("sha256")
+			return installFrom(stderr, from, sha256, sdksDir)
 		}
 
-		// Create GOPATH directory for this version
-		gopath, err := config.GetVersionGopath(normalizedVersion)
-		if err != nil {
-			fmt.Printf("Error getting gopath: %v\n", err)
-			os.Exit(1)
+		source, _ := cmd.Flags().GetString("source")
+		if len(args) == 1 && args[0] == downloader.Tip {
+			source = downloader.TipRef
 		}
-
-		if err := os.MkdirAll(gopath, 0755); err != nil {
-			fmt.Printf("Error creating gopath directory: %v\n", err)
-			os.Exit(1)
+		if source != "" {
+			bootstrap, _ := cmd.Flags().GetString("bootstrap")
+			return installSource(stderr, source, bootstrap, sdksDir)
 		}
 
-		// Create standard GOPATH subdirectories
-		for _, subdir := range []string{"src", "bin", "pkg"} {
-			if err := os.MkdirAll(filepath.Join(gopath, subdir), 0755); err != nil {
-				fmt.Printf("Error creating gopath subdirectory %s: %v\n", subdir, err)
-				os.Exit(1)
-			}
+		if len(args) > 1 {
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			return installVersions(stderr, args, jobs, platform, distsDir, sdksDir)
 		}
 
-		// Initialize GOENV file for this version
-		goenvPath, err := config.GetVersionGoenv(normalizedVersion)
+		// Normalize version (remove 'go' prefix, resolve aliases like 'latest')
+		normalizedVersion, err := resolveVersionFor(stderr, args[0], sdksDir, platform)
 		if err != nil {
-			fmt.Printf("Error getting goenv path: %v\n", err)
-			os.Exit(1)
+			return err
 		}
+		name := downloader.SDKName(normalizedVersion, platform)
 
-		// Create goenvs directory if it doesn't exist
-		goenvsDir := filepath.Dir(goenvPath)
-		if err := os.MkdirAll(goenvsDir, 0755); err != nil {
-			fmt.Printf("Error creating goenvs directory: %v\n", err)
-			os.Exit(1)
+		// Serialize with other processes installing or removing this version
+		sdkLock, err := acquireLock(lock.SDK(name))
+		if err != nil {
+			return err
 		}
+		defer func() {
+			_ = sdkLock.Release()
+		}()
 
-		// Create empty GOENV file (GOROOT and GOPATH are set by vg init, not in this file)
-		// Users can add custom environment variables here or use 'go env -w KEY=VALUE'
-		goenvContent := "# This file is managed by vg.\n# GOROOT and GOPATH are set automatically by 'vg init'.\n# You can add custom environment variables below or use 'go env -w KEY=VALUE'\n"
-		if err := os.WriteFile(goenvPath, []byte(goenvContent), 0644); err != nil {
-			fmt.Printf("Error creating goenv file: %v\n", err)
-			os.Exit(1)
+		if err := service.CheckNotInstalled(sdksDir, name); err != nil {
+			return err
 		}
 
-		// Create GOCACHE directory for this version
-		gocache, err := config.GetVersionGocache(normalizedVersion)
-		if err != nil {
-			fmt.Printf("Error getting gocache: %v\n", err)
-			os.Exit(1)
+		if platform.IsNative() {
+			fmt.Fprintf(stderr, "Installing Go %s...\n", normalizedVersion)
+		} else {
+			fmt.Fprintf(stderr, "Installing Go %s for %s...\n", normalizedVersion, platform)
 		}
-
-		if err := os.MkdirAll(gocache, 0755); err != nil {
-			fmt.Printf("Error creating gocache directory: %v\n", err)
-			os.Exit(1)
+		installer := downloader.NewInstaller()
+		installer.Log = stderr
+		installer.Platform = platform
+		if err := service.Install(installer, normalizedVersion, distsDir, sdksDir); err != nil {
+			return err
 		}
 
-		fmt.Printf("✅ Created GOPATH: %s\n", gopath)
-		fmt.Printf("✅ Created GOENV: %s\n", goenvPath)
-		fmt.Printf("✅ Created GOCACHE: %s\n", gocache)
+		refreshShims()
+		return nil
 	},
 }
 
+// resolveVersion normalizes a version argument and resolves aliases such as
+// 'latest' or '1.23' to an exact version, reporting the resolution to w.
+func resolveVersion(w io.Writer, version, sdksDir string) (string, error) {
+	return resolveVersionFor(w, version, sdksDir, downloader.NativePlatform())
+}
+
+// resolveVersionFor is like resolveVersion for the releases of platform.
+func resolveVersionFor(w io.Writer, version, sdksDir string, platform downloader.Platform) (string, error) {
+	resolved, err := downloader.ResolveVersionFor(version, sdksDir, platform)
+	if err != nil {
+		return "", err
+	}
+	if resolved != strings.TrimPrefix(version, "go") {
+		fmt.Fprintf(w, "Resolved %s to Go %s\n", version, resolved)
+	}
+	return resolved, nil
+}
+
 func init() {
 	rootCmd.AddCommand(installCmd)
+
+	installCmd.Flags().IntP("jobs", "j", 3, "Number of versions to install concurrently")
+	installCmd.Flags().String("from", "", "Install from a local archive or GOROOT directory instead of downloading")
+	installCmd.Flags().String("sha256", "", "Expected SHA-256 checksum of the --from archive")
+	installCmd.Flags().String("source", "", "Build from a git ref, local git checkout or source tarball")
+	installCmd.Flags().String("os", runtime.GOOS, "Operating system to install SDKs for")
+	installCmd.Flags().String("arch", runtime.GOARCH, "Architecture to install SDKs for")
+	installCmd.Flags().String("bootstrap", "", "Installed Go version used as GOROOT_BOOTSTRAP for --source builds")
+}
+
+// installPlatform returns the platform selected with --os and --arch.
+func installPlatform(cmd *cobra.Command) (downloader.Platform, error) {
+	goos, _ := cmd.Flags().GetString("os")
+	goarch, _ := cmd.Flags().GetString("arch")
+	platform := downloader.Platform{OS: goos, Arch: goarch}
+	if err := downloader.ValidatePlatform(platform); err != nil {
+		return platform, err
+	}
+	return platform, nil
 }
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
+)
+
+// installFrom installs the SDK in a local archive or GOROOT directory,
+// reporting progress to w.
+func installFrom(w io.Writer, path, sha256, sdksDir string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	version, err := downloader.LocalVersion(path)
+	if err != nil {
+		return err
+	}
+	platform, err := downloader.LocalPlatform(path)
+	if err != nil {
+		return err
+	}
+	name := downloader.SDKName(version, platform)
+	if platform.IsNative() {
+		fmt.Fprintf(w, "Found Go %s in %s\n", version, path)
+	} else {
+		fmt.Fprintf(w, "Found Go %s for %s in %s\n", version, platform, path)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() && sha256 == "" && cfg.Download.Checksum != config.ChecksumOff {
+		sha256, err = downloader.LocalChecksum(path)
+		if err != nil {
+			return withHint(fmt.Errorf("cannot verify %s: %w", path, err), "Pass the expected checksum with --sha256.")
+		}
+	}
+
+	// Serialize with other processes installing or removing this version
+	sdkLock, err := acquireLock(lock.SDK(name))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = sdkLock.Release()
+	}()
+
+	if err := service.CheckNotInstalled(sdksDir, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Installing Go %s...\n", name)
+	installer := downloader.NewInstaller()
+	installer.Log = w
+	installer.Config = cfg
+	if err := installer.InstallFrom(path, name, sha256, sdksDir); err != nil {
+		return err
+	}
+	if !platform.IsNative() {
+		// Nothing runs a foreign SDK here, so it needs no GOPATH or caches
+		fmt.Fprintf(w, "✅ Installed Go %s for %s as %s\n", version, platform, name)
+		return nil
+	}
+	if err := service.CreateVersionDirs(installer.Log, version); err != nil {
+		return err
+	}
+
+	refreshShims()
+	return nil
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
+)
+
+// installResult is the outcome of installing one requested version.
+type installResult struct {
+	version string
+	err     error
+}
+
+// installFailures is returned by installVersions when some versions could
+// not be installed.
+type installFailures struct {
+	total int
+	errs  []error
+}
+
+func (e *installFailures) Error() string {
+	return fmt.Sprintf("%d of %d versions failed to install", len(e.errs), e.total)
+}
+
+func (e *installFailures) Unwrap() []error {
+	return e.errs
+}
+
+// installVersions installs several versions concurrently, at most jobs at a
+// time, showing one progress line per version on w. It prints a summary to w
+// and returns an installFailures error unless every version was installed.
+func installVersions(w io.Writer, versions []string, jobs int, platform downloader.Platform, distsDir, sdksDir string) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	labels := make([]string, len(versions))
+	for i, v := range versions {
+		labels[i] = "Go " + v
+	}
+	bars := newMultiBar(w, labels)
+	for i := range versions {
+		bars.Set(i, labels[i]+": waiting")
+	}
+
+	results := make([]installResult, len(versions))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range min(jobs, len(versions)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				installer := &downloader.Installer{Log: bars.Log(i), NewBar: bars.NewBar(i), Platform: platform}
+				results[i] = installOne(installer, versions[i], distsDir, sdksDir)
+				if results[i].err != nil {
+					bars.Set(i, fmt.Sprintf("❌ %s: %v", labels[i], results[i].err))
+				} else {
+					bars.Set(i, fmt.Sprintf("✅ Go %s installed", results[i].version))
+				}
+			}
+		}()
+	}
+	for i := range versions {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	failures := &installFailures{total: len(results)}
+	fmt.Fprintln(w, "\nSummary:")
+	for i, r := range results {
+		if r.err != nil {
+			failures.errs = append(failures.errs, r.err)
+			fmt.Fprintf(w, "  ❌ %s: %v\n", versions[i], r.err)
+		} else {
+			fmt.Fprintf(w, "  ✅ %s\n", r.version)
+		}
+	}
+	if len(failures.errs) < len(results) {
+		refreshShims()
+	}
+	if len(failures.errs) > 0 {
+		return failures
+	}
+	return nil
+}
+
+// installOne resolves and installs a single version for installVersions.
+func installOne(installer *downloader.Installer, version, distsDir, sdksDir string) installResult {
+	normalizedVersion, err := downloader.ResolveVersionFor(version, sdksDir, installer.Platform)
+	if err != nil {
+		return installResult{version: version, err: err}
+	}
+	if normalizedVersion != strings.TrimPrefix(version, "go") {
+		fmt.Fprintf(installer.Log, "Resolved %s to Go %s\n", version, normalizedVersion)
+	}
+	name := downloader.SDKName(normalizedVersion, installer.Platform)
+	result := installResult{version: name}
+
+	lockName := lock.SDK(name)
+	sdkLock, err := lock.Acquire(lockName, lockTimeout, func(holder string) {
+		fmt.Fprintf(installer.Log, "⏳ Waiting for lock '%s' held by %s...\n", lockName, holder)
+	})
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer func() {
+		_ = sdkLock.Release()
+	}()
+
+	result.err = service.Install(installer, normalizedVersion, distsDir, sdksDir)
+	return result
+}
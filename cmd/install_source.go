@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
+)
+
+// installSource builds Go from the source named by spec and installs it,
+// reporting progress to w.
+func installSource(w io.Writer, spec, bootstrapVersion, sdksDir string) error {
+	srcDir, err := config.GetSrcDir()
+	if err != nil {
+		return fmt.Errorf("error getting src dir: %w", err)
+	}
+
+	bootstrap, err := downloader.BootstrapGoroot(sdksDir, bootstrapVersion)
+	if err != nil {
+		return err
+	}
+
+	installer := downloader.NewInstaller()
+	installer.Log = w
+
+	// Serialize fetches into the shared repository clone
+	srcLock, err := acquireLock(lock.Source)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = srcLock.Release()
+	}()
+
+	src, err := installer.FindSource(spec, srcDir)
+	if err != nil {
+		return err
+	}
+
+	sdkLock, err := acquireLock(lock.SDK(src.Version))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = sdkLock.Release()
+	}()
+
+	if err := service.CheckNotInstalled(sdksDir, src.Version); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Building Go %s from source...\n", src.Version)
+	if err := installer.BuildSource(src, bootstrap, sdksDir); err != nil {
+		return err
+	}
+	if err := service.CreateVersionDirs(installer.Log, src.Version); err != nil {
+		return err
+	}
+
+	refreshShims()
+	return nil
+}
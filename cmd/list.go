@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
-	"strings"
 
 	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/output"
 
 	"github.com/spf13/cobra"
 )
@@ -15,88 +15,155 @@ import (
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all installed Go versions and their virtual environments",
-	Run: func(cmd *cobra.Command, args []string) {
-		sdksDir, err := config.GetSdksDir()
-		if err != nil {
-			fmt.Printf("Error getting sdks dir: %v\n", err)
-			os.Exit(1)
-		}
+	Long: `List all installed Go versions and their virtual environments.
 
-		// Check if sdks directory exists
-		if _, err := os.Stat(sdksDir); os.IsNotExist(err) {
-			fmt.Println("No Go versions installed yet.")
-			return
+Versions are listed from oldest to newest with their install date and size
+on disk, as recorded at install time. The active version and virtual environment are marked with '*'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat()
+		if err != nil {
+			return err
 		}
 
-		// Read directory
-		entries, err := os.ReadDir(sdksDir)
+		sdksDir, err := config.GetSdksDir()
 		if err != nil {
-			fmt.Printf("Error reading sdks directory: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting sdks dir: %w", err)
 		}
 
-		if len(entries) == 0 {
-			fmt.Println("No Go versions installed yet.")
-			return
+		// Read directory; it does not exist before the first install
+		entries, err := os.ReadDir(sdksDir)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error reading sdks directory: %w", err)
 		}
 
-		// Collect version directories
+		// Collect version directories, skipping in-progress installs
 		var versions []string
 		for _, entry := range entries {
-			if entry.IsDir() {
+			if entry.IsDir() && !downloader.IsStaging(entry.Name()) {
 				versions = append(versions, entry.Name())
 			}
 		}
 
-		if len(versions) == 0 {
+		if len(versions) == 0 && format == output.Table {
 			fmt.Println("No Go versions installed yet.")
-			return
+			return nil
+		}
+
+		// Sort versions, oldest first
+		downloader.SortSDKNames(versions)
+
+		// The active version and environment, if any
+		var active *output.Active
+		if current, err := activeVersion(); err == nil {
+			active = &output.Active{
+				Version: current,
+				Env:     activeEnv(),
+				Session: os.Getenv(sessionVersionVar) != "",
+			}
+		}
+
+		doc := output.VersionList{
+			Header:   output.NewHeader(output.KindVersionList),
+			Active:   active,
+			Versions: make([]output.Version, 0, len(versions)),
+		}
+		for _, name := range versions {
+			doc.Versions = append(doc.Versions, installedVersion(sdksDir, name, active))
 		}
 
-		// Sort versions
-		sort.Strings(versions)
+		if format != output.Table {
+			return writeOutput(format, doc)
+		}
 
-		// Get envs root dir
-		envsRoot, _ := config.GetEnvsDir()
+		width := 0
+		for _, v := range doc.Versions {
+			width = max(width, len(v.Name))
+		}
 
 		// Display
-		fmt.Printf("Installed Go versions (%d):\n", len(versions))
-		for _, version := range versions {
-			fmt.Printf("  - %s\n", version)
-
-			// Check for virtual environments
-			if envsRoot != "" {
-				versionEnvsDir := filepath.Join(envsRoot, version)
-				if envEntries, err := os.ReadDir(versionEnvsDir); err == nil {
-					var envs []string
-					for _, envEntry := range envEntries {
-						if envEntry.IsDir() {
-							envs = append(envs, envEntry.Name())
-						}
-					}
-
-					if len(envs) > 0 {
-						sort.Strings(envs)
-						for _, envName := range envs {
-							remark := ""
-							remarkPath := filepath.Join(versionEnvsDir, envName, "remark.txt")
-							if data, err := os.ReadFile(remarkPath); err == nil {
-								remark = strings.TrimSpace(string(data))
-							}
-
-							if remark != "" {
-								fmt.Printf("      * %s (%s)\n", envName, remark)
-							} else {
-								fmt.Printf("      * %s\n", envName)
-							}
-						}
-					}
+		fmt.Printf("Installed Go versions (%d):\n", len(doc.Versions))
+		for _, v := range doc.Versions {
+			installed, size := "-", "-"
+			if v.InstalledAt != nil {
+				installed = v.InstalledAt.Local().Format("2006-01-02")
+			}
+			if v.SizeBytes > 0 {
+				size = formatSize(v.SizeBytes)
+			}
+
+			var note string
+			if !v.Complete {
+				note = fmt.Sprintf("  (incomplete, run 'vg install %s' to repair)", v.Name)
+			} else if !v.Native {
+				note = fmt.Sprintf("  (%s, not runnable here)", v.Platform)
+			}
+			fmt.Printf("  %s %-*s  %-10s  %9s%s\n", activeMark(v.Active), width, v.Name, installed, size, note)
+
+			// Virtual environments
+			for _, env := range v.Envs {
+				if env.Remark != "" {
+					fmt.Printf("      %s %s (%s)\n", activeMark(env.Active), env.Name, env.Remark)
+				} else {
+					fmt.Printf("      %s %s\n", activeMark(env.Active), env.Name)
 				}
 			}
 		}
+		return nil
 	},
 }
 
+// installedVersion describes the SDK name in sdksDir.
+func installedVersion(sdksDir, name string, active *output.Active) output.Version {
+	number, platform := downloader.SplitSDKName(name)
+	v := output.Version{
+		Name:     name,
+		Version:  number,
+		Platform: platform.String(),
+		Native:   platform.IsNative(),
+		Complete: downloader.IsInstalled(sdksDir, name),
+		Active:   active != nil && active.Version == name,
+		Goroot:   filepath.Join(sdksDir, name),
+	}
+	// The size is recorded at install time; walking every SDK is too slow
+	if m, err := downloader.ReadMarker(v.Goroot); err == nil {
+		v.InstalledAt = &m.InstalledAt
+		v.SizeBytes = m.SizeBytes
+	}
+
+	activeName := ""
+	if v.Active {
+		activeName = active.Env
+	}
+	if envs, err := versionEnvs(name, activeName); err == nil {
+		v.Envs = envs
+	} else {
+		v.Envs = []output.Env{}
+	}
+	return v
+}
+
+// activeMark returns the marker of active entries in table output.
+func activeMark(active bool) string {
+	if active {
+		return "*"
+	}
+	return "-"
+}
+
+// formatSize formats a byte count for humans, e.g. "243.5 MB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/project"
+
+	"github.com/spf13/cobra"
+)
+
+var localCmd = &cobra.Command{
+	Use:   "local [version]",
+	Short: "Pin a Go version for the current directory",
+	Long: `Pin a Go version for the current directory by writing a .go-version file.
+
+Running 'vg use' without an argument in this directory or any of its
+subdirectories will then switch to the pinned version. Without an argument,
+'vg local' prints the version pinned for the current directory.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("error getting working directory: %w", err)
+		}
+
+		if len(args) == 0 {
+			version, source, err := project.FindVersion(wd)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s (from %s)\n", version, source)
+			return nil
+		}
+
+		sdksDir, err := config.GetSdksDir()
+		if err != nil {
+			return fmt.Errorf("error getting sdks dir: %w", err)
+		}
+
+		// Pin an exact version so the project does not drift with new releases
+		normalizedVersion, err := resolveVersion(cmd.ErrOrStderr(), args[0], sdksDir)
+		if err != nil {
+			return err
+		}
+
+		path, err := project.WriteVersionFile(wd, normalizedVersion)
+		if err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+
+		fmt.Printf("✅ Pinned Go %s in %s\n", normalizedVersion, path)
+		fmt.Println("\nRun 'vg use' in this directory to activate it.")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(localCmd)
+}
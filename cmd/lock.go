@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fun7257/vg/internal/activation"
+	"github.com/fun7257/vg/internal/lock"
+)
+
+// lockTimeout bounds how long commands wait for another vg process.
+var lockTimeout time.Duration
+
+// acquireLock takes the named lock, telling the user who holds it while
+// waiting. It fails with lock.ErrTimeout after --lock-timeout.
+func acquireLock(name string) (*lock.Lock, error) {
+	return lock.Acquire(name, lockTimeout, func(holder string) {
+		fmt.Fprintf(os.Stderr, "⏳ Waiting for lock '%s' held by %s...\n", name, holder)
+	})
+}
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 0, "Maximum time to wait for another vg process, e.g. 30s (0 waits indefinitely)")
+}
+
+// recoverActivation completes a switch of the 'current' symlinks that was
+// interrupted by a crash, so that no command reads half-switched links.
+func recoverActivation() error {
+	if !activation.Interrupted() {
+		return nil
+	}
+	activationLock, err := acquireLock(lock.Activation)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = activationLock.Release()
+	}()
+	return activation.Recover()
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/goversion"
+	"github.com/fun7257/vg/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var lsRemoteCmd = &cobra.Command{
+	Use:   "ls-remote [prefix]",
+	Short: "List Go versions available for installation",
+	Long: `List Go versions available for installation on this platform.
+
+By default only the currently supported releases are shown. Use --all to
+list every published release, or pass a prefix such as '1.22' to list a
+single release line. Versions are listed from newest to oldest.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+		all, _ := cmd.Flags().GetBool("all")
+		stableOnly, _ := cmd.Flags().GetBool("stable")
+
+		prefix := ""
+		if len(args) == 1 {
+			prefix = args[0]
+			// Older release lines are only present in the full index
+			all = true
+		}
+
+		sdksDir, err := config.GetSdksDir()
+		if err != nil {
+			return fmt.Errorf("error getting sdks dir: %w", err)
+		}
+
+		client, err := downloader.NewIndexClient()
+		if err != nil {
+			return err
+		}
+
+		releases, err := client.Releases(all)
+		if err != nil {
+			return err
+		}
+
+		var versions []string
+		installed := make(map[string]bool)
+		stable := make(map[string]bool)
+		for _, r := range releases {
+			if stableOnly && !r.Stable {
+				continue
+			}
+			if r.Archive(runtime.GOOS, runtime.GOARCH) == nil {
+				continue
+			}
+			version := r.Number()
+			if !downloader.MatchPrefix(version, prefix) {
+				continue
+			}
+			versions = append(versions, version)
+			stable[version] = r.Stable
+			if downloader.IsInstalled(sdksDir, version) {
+				installed[version] = true
+			}
+		}
+
+		// Newest first, whatever order the mirror lists them in
+		slices.SortStableFunc(versions, func(a, b string) int {
+			return goversion.Compare(b, a)
+		})
+
+		if format != output.Table {
+			doc := output.RemoteVersionList{
+				Header:   output.NewHeader(output.KindRemoteVersionList),
+				Platform: runtime.GOOS + "/" + runtime.GOARCH,
+				Versions: make([]output.RemoteVersion, 0, len(versions)),
+			}
+			for _, version := range versions {
+				doc.Versions = append(doc.Versions, output.RemoteVersion{
+					Version:   version,
+					Stable:    stable[version],
+					Installed: installed[version],
+				})
+			}
+			return writeOutput(format, doc)
+		}
+
+		if len(versions) == 0 {
+			fmt.Printf("No matching Go versions available for %s/%s.\n", runtime.GOOS, runtime.GOARCH)
+			return nil
+		}
+
+		fmt.Printf("Available Go versions for %s/%s (%d):\n", runtime.GOOS, runtime.GOARCH, len(versions))
+		for _, version := range versions {
+			if installed[version] {
+				fmt.Printf("  - %s (installed)\n", version)
+			} else {
+				fmt.Printf("  - %s\n", version)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lsRemoteCmd)
+
+	lsRemoteCmd.Flags().BoolP("all", "a", false, "List all published releases, not only supported ones")
+	lsRemoteCmd.Flags().Bool("stable", false, "List stable releases only (hide rc and beta)")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+// multiBarWidth bounds the length of a line so that it never wraps, which
+// would break redrawing the lines in place.
+const multiBarWidth = 78
+
+// multiBar shows the progress of several concurrent tasks, one line each.
+// On a terminal the lines are redrawn in place; otherwise each status
+// message is printed as it arrives, prefixed with the task label, and
+// progress bars are not shown.
+type multiBar struct {
+	mu       sync.Mutex
+	out      io.Writer
+	terminal bool
+	labels   []string
+	lines    []string
+	drawn    int
+}
+
+func newMultiBar(out io.Writer, labels []string) *multiBar {
+	f, ok := out.(*os.File)
+	return &multiBar{
+		out:      out,
+		terminal: ok && isTerminal(f),
+		labels:   labels,
+		lines:    make([]string, len(labels)),
+	}
+}
+
+// Log returns a writer for the status messages of task i.
+func (m *multiBar) Log(i int) io.Writer {
+	return &multiBarLog{m: m, i: i}
+}
+
+// NewBar returns a byte progress bar drawn on the line of task i.
+func (m *multiBar) NewBar(i int) func(total int64) *progressbar.ProgressBar {
+	return func(total int64) *progressbar.ProgressBar {
+		var w io.Writer = io.Discard
+		if m.terminal {
+			w = &multiBarLine{m: m, i: i}
+		}
+		return progressbar.NewOptions64(total,
+			progressbar.OptionSetDescription(m.labels[i]),
+			progressbar.OptionSetWriter(w),
+			progressbar.OptionShowBytes(true),
+			progressbar.OptionShowTotalBytes(true),
+			progressbar.OptionSetWidth(10),
+			progressbar.OptionThrottle(65*time.Millisecond),
+			progressbar.OptionShowCount(),
+			progressbar.OptionSpinnerType(14),
+		)
+	}
+}
+
+// Set replaces the line of task i.
+func (m *multiBar) Set(i int, line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(i, line)
+}
+
+func (m *multiBar) set(i int, line string) {
+	if !m.terminal {
+		_, _ = fmt.Fprintln(m.out, line)
+		return
+	}
+
+	if r := []rune(line); len(r) > multiBarWidth {
+		line = string(r[:multiBarWidth-1]) + "…"
+	}
+	m.lines[i] = line
+
+	// Move back to the first line and redraw all of them
+	if m.drawn > 0 {
+		_, _ = fmt.Fprintf(m.out, "\033[%dA", m.drawn)
+	}
+	for _, l := range m.lines {
+		_, _ = fmt.Fprintf(m.out, "\r\033[2K%s\n", l)
+	}
+	m.drawn = len(m.lines)
+}
+
+// multiBarLog turns status messages into lines of a multiBar.
+type multiBarLog struct {
+	m *multiBar
+	i int
+}
+
+func (l *multiBarLog) Write(p []byte) (int, error) {
+	l.m.mu.Lock()
+	defer l.m.mu.Unlock()
+	for _, line := range strings.Split(string(p), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			l.m.set(l.i, l.m.labels[l.i]+": "+line)
+		}
+	}
+	return len(p), nil
+}
+
+// multiBarLine receives the rendering of a progress bar and shows it as a
+// line of a multiBar.
+type multiBarLine struct {
+	m *multiBar
+	i int
+}
+
+func (l *multiBarLine) Write(p []byte) (int, error) {
+	s := strings.ReplaceAll(string(p), "\033[2K", "")
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\r", ""))
+	if s != "" {
+		l.m.Set(l.i, s)
+	}
+	return len(p), nil
+}
+
+// isTerminal reports whether f is connected to a terminal.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/output"
+	"github.com/fun7257/vg/internal/service"
+)
+
+// outputFlag is the value of the global --output flag.
+var outputFlag string
+
+// outputFormat returns the format selected with --output.
+func outputFormat() (output.Format, error) {
+	format, err := output.ParseFormat(outputFlag)
+	if err != nil {
+		return "", &service.UsageError{Err: err}
+	}
+	return format, nil
+}
+
+// writeOutput writes a structured output document to stdout.
+func writeOutput(format output.Format, doc any) error {
+	return output.Write(os.Stdout, format, doc)
+}
+
+// versionEnvs returns the virtual environments of version sorted by name,
+// marking activeName as active.
+func versionEnvs(version, activeName string) ([]output.Env, error) {
+	envsRoot, err := config.GetEnvsDir()
+	if err != nil {
+		return nil, err
+	}
+	versionEnvsDir := filepath.Join(envsRoot, version)
+	entries, err := os.ReadDir(versionEnvsDir)
+	if os.IsNotExist(err) {
+		return []output.Env{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	envs := []output.Env{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		env := output.Env{Name: entry.Name(), Active: entry.Name() == activeName}
+		if data, err := os.ReadFile(filepath.Join(versionEnvsDir, env.Name, "remark.txt")); err == nil {
+			env.Remark = strings.TrimSpace(string(data))
+		}
+		envs = append(envs, env)
+	}
+	sort.Slice(envs, func(i, j int) bool { return envs[i].Name < envs[j].Name })
+	return envs, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", string(output.Table), "Output format of list, status, env list, ls-remote and config list: table, json or yaml")
+}
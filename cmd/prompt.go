@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// nonInteractiveVar disables all prompts when set to a true value, as if
+// standard input were not a terminal.
+const nonInteractiveVar = "VG_NONINTERACTIVE"
+
+// errNoPrompt is returned by confirm when no one can answer.
+var errNoPrompt = errors.New("cannot prompt")
+
+// nonInteractiveReason returns why vg must not prompt, or "" if it may.
+func nonInteractiveReason() string {
+	if value := os.Getenv(nonInteractiveVar); value != "" {
+		if set, err := strconv.ParseBool(value); err != nil || set {
+			return nonInteractiveVar + " is set"
+		}
+	}
+	if !isTerminal(os.Stdin) {
+		return "standard input is not a terminal"
+	}
+	return ""
+}
+
+// confirm asks a yes/no question on the terminal; the default is no. It
+// returns an error wrapping errNoPrompt instead of waiting for an answer
+// that cannot come.
+func confirm(question string) (bool, error) {
+	if reason := nonInteractiveReason(); reason != "" {
+		return false, fmt.Errorf("%w: %s", errNoPrompt, reason)
+	}
+	fmt.Printf("%s [y/N] ", question)
+
+	response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("error reading response: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
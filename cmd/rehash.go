@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/lock"
+
+	"github.com/spf13/cobra"
+)
+
+// sdkTools are shimmed regardless of what is installed in GOPATH/bin.
+var sdkTools = []string{"go", "gofmt"}
+
+var rehashCmd = &cobra.Command{
+	Use:   "rehash",
+	Short: "Regenerate the shims in the vg shims directory",
+	Long: `Regenerate the shims in the vg shims directory (~/.vg/shims by default).
+
+The shims directory holds 'go', 'gofmt' and one shim per binary in the active
+GOPATH/bin. Put it on PATH for programs that never source 'vg init', such as
+IDEs, cron jobs and Makefiles. Each shim picks the Go version when it runs:
+
+  1. the VG_VERSION environment variable
+  2. the nearest .go-version, .tool-versions or go.mod (toolchain or go
+     directive), searching upwards from the working directory, as 'vg use'
+     does; aliases such as 1.22 pick the newest installed match
+  3. the global version selected with 'vg use'
+
+Shims are refreshed automatically by install, use and env commands.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shimsDir, count, err := rehashShims()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Generated %d shims in %s\n", count, shimsDir)
+		return nil
+	},
+}
+
+// rehashShims rewrites the shims directory and returns its path and the
+// number of shims written. Each shim is replaced with a rename, so programs
+// on PATH always find either the old or the new shim; shims of removed
+// binaries are deleted afterwards.
+func rehashShims() (string, int, error) {
+	shimsDir, err := config.GetShimsDir()
+	if err != nil {
+		return "", 0, err
+	}
+
+	shimsLock, err := acquireLock(lock.Shims)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		_ = shimsLock.Release()
+	}()
+
+	vgBin, err := os.Executable()
+	if err != nil {
+		return "", 0, fmt.Errorf("error locating vg binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(vgBin); err == nil {
+		vgBin = resolved
+	}
+
+	names := append([]string{}, sdkTools...)
+	if gopathLink, err := config.GetCurrentGopathLink(); err == nil {
+		entries, _ := os.ReadDir(filepath.Join(gopathLink, "bin"))
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+
+	if err := os.MkdirAll(shimsDir, 0755); err != nil {
+		return "", 0, fmt.Errorf("error creating shims directory: %w", err)
+	}
+
+	written := make(map[string]bool)
+	for _, name := range names {
+		if written[name] || strings.HasPrefix(name, shimTempPrefix) {
+			continue
+		}
+		content := fmt.Sprintf("#!/bin/sh\n# Generated by 'vg rehash'. Do not edit.\nexec %s shim %s \"$@\"\n",
+			quoteShimArg(vgBin), quoteShimArg(name))
+		if err := writeShim(shimsDir, name, content); err != nil {
+			return "", 0, fmt.Errorf("error writing shim %s: %w", name, err)
+		}
+		written[name] = true
+	}
+
+	// Remove the shims of binaries that are gone, and temporary files left
+	// by an interrupted rehash, which is safe under the lock
+	entries, err := os.ReadDir(shimsDir)
+	if err != nil {
+		return "", 0, fmt.Errorf("error reading shims directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !written[entry.Name()] {
+			if err := os.RemoveAll(filepath.Join(shimsDir, entry.Name())); err != nil {
+				return "", 0, fmt.Errorf("error removing old shim %s: %w", entry.Name(), err)
+			}
+		}
+	}
+	return shimsDir, len(written), nil
+}
+
+// shimTempPrefix starts the names of shims being written.
+const shimTempPrefix = ".vg-shim-"
+
+// writeShim replaces the shim name in dir with content through a temporary
+// file, which a rename moves into place atomically.
+func writeShim(dir, name, content string) error {
+	f, err := os.CreateTemp(dir, shimTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0755)
+	}
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(dir, name))
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// refreshShims regenerates the shims after a command changed what is
+// installed or active. Failures only warrant a warning.
+func refreshShims() {
+	if _, _, err := rehashShims(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to refresh shims: %v\n", err)
+	}
+}
+
+func quoteShimArg(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	rootCmd.AddCommand(rehashCmd)
+}
@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/service"
 
 	"github.com/spf13/cobra"
 )
@@ -15,118 +18,83 @@ import (
 var rmCmd = &cobra.Command{
 	Use:   "rm [version]",
 	Short: "Remove a specific Go version",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Remove a specific Go version with its GOPATH, GOENV, GOCACHE and virtual
+environments.
+
+The version may be exact or an alias such as 1.23 or tip, resolved against
+the installed SDKs only: 1.23 removes the newest installed 1.23 release and
+tip the most recently installed source build.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Progress goes to stderr, keeping stdout clean for wrapper scripts
+		stderr := cmd.ErrOrStderr()
 		version := args[0]
-		// Normalize version (remove 'go' prefix if present)
-		normalizedVersion := strings.TrimPrefix(version, "go")
-
 		sdksDir, err := config.GetSdksDir()
 		if err != nil {
-			fmt.Printf("Error getting sdks dir: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting sdks dir: %w", err)
 		}
 
-		// Check if version exists
-		versionPath := filepath.Join(sdksDir, normalizedVersion)
-		if _, err := os.Stat(versionPath); os.IsNotExist(err) {
-			fmt.Printf("❌ Go version %s is not installed\n", version)
-			fmt.Println("\nRun 'vg list' to see installed versions")
-			os.Exit(1)
+		// Normalize version (remove 'go' prefix, resolve aliases like 'tip'
+		// against the installed SDKs)
+		normalizedVersion := downloader.ResolveInstalled(version, sdksDir)
+		if normalizedVersion != strings.TrimPrefix(version, "go") {
+			fmt.Fprintf(stderr, "Resolved %s to Go %s\n", version, normalizedVersion)
 		}
 
-		// Check if this version is currently in use
-		currentLink, err := config.GetCurrentLink()
+		// Keep 'vg use' from activating the version while it is removed
+		sdkLock, err := acquireLock(lock.SDK(normalizedVersion))
 		if err != nil {
-			fmt.Printf("Error getting current link: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-
-		if target, err := os.Readlink(currentLink); err == nil {
-			currentVersion := filepath.Base(target)
-			if currentVersion == normalizedVersion {
-				fmt.Printf("❌ Cannot remove Go %s: it is currently in use\n", normalizedVersion)
-				fmt.Printf("\nTo remove this version, first switch to another version:\n")
-				fmt.Printf("  vg use <other-version>\n")
-				fmt.Printf("  vg rm %s\n", normalizedVersion)
-				os.Exit(1)
-			}
+		defer func() {
+			_ = sdkLock.Release()
+		}()
+		activationLock, err := acquireLock(lock.Activation)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = activationLock.Release()
+		}()
+
+		var notInstalledErr *service.NotInstalledError
+		var inUseErr *service.InUseError
+		err = service.CheckRemovable(sdksDir, normalizedVersion)
+		switch {
+		case errors.As(err, &notInstalledErr):
+			return withHint(err, "Run 'vg list' to see installed versions")
+		case errors.As(err, &inUseErr):
+			return withHint(err, "To remove this version, first switch to another version:\n  vg use <other-version>\n  vg rm %s", normalizedVersion)
+		case err != nil:
+			return err
 		}
 
 		// Confirm deletion
-		fmt.Printf("Removing Go version %s...\n", normalizedVersion)
+		fmt.Fprintf(stderr, "Removing Go version %s...\n", normalizedVersion)
 
 		// Show progress animation
 		done := make(chan bool)
-		go showProgress(done)
+		go showProgress(stderr, done)
 
-		// Perform deletion of SDK
-		err = os.RemoveAll(versionPath)
-		if err != nil {
-			done <- true
-			<-done
-			fmt.Printf("\n❌ Error removing SDK: %v\n", err)
-			os.Exit(1)
-		}
-
-		// Remove GOPATH for this version
-		gopath, err := config.GetVersionGopath(normalizedVersion)
-		if err == nil {
-			if _, err := os.Stat(gopath); err == nil {
-				if err := os.RemoveAll(gopath); err != nil {
-					done <- true
-					<-done
-					fmt.Printf("\n⚠️  Warning: Error removing GOPATH: %v\n", err)
-				}
-			}
-		}
+		// Delete the SDK, GOPATH, GOENV, GOCACHE and virtual environments
+		warnings, err := service.Remove(sdksDir, normalizedVersion)
 
-		// Remove GOENV for this version
-		goenvPath, err := config.GetVersionGoenv(normalizedVersion)
-		if err == nil {
-			if _, err := os.Stat(goenvPath); err == nil {
-				if err := os.Remove(goenvPath); err != nil {
-					done <- true
-					<-done
-					fmt.Printf("\n⚠️  Warning: Error removing GOENV: %v\n", err)
-				}
-			}
-		}
+		done <- true
+		<-done // Wait for animation to finish
 
-		// Remove GOCACHE for this version
-		gocache, err := config.GetVersionGocache(normalizedVersion)
-		if err == nil {
-			if _, err := os.Stat(gocache); err == nil {
-				if err := os.RemoveAll(gocache); err != nil {
-					done <- true
-					<-done
-					fmt.Printf("\n⚠️  Warning: Error removing GOCACHE: %v\n", err)
-				}
-			}
+		if err != nil {
+			return err
 		}
-
-		// Remove Virtual Environments for this version
-		// envsRoot/version/
-		envsRoot, err := config.GetEnvsDir()
-		if err == nil {
-			versionEnvsDir := filepath.Join(envsRoot, normalizedVersion)
-			if _, err := os.Stat(versionEnvsDir); err == nil {
-				if err := os.RemoveAll(versionEnvsDir); err != nil {
-					done <- true
-					<-done
-					fmt.Printf("\n⚠️  Warning: Error removing Virtual Environments: %v\n", err)
-				}
-			}
+		for _, w := range warnings {
+			fmt.Fprintf(stderr, "⚠️  Warning: %v\n", w)
 		}
 
-		done <- true
-		<-done // Wait for animation to finish
-
-		fmt.Printf("\n✅ Successfully removed Go %s (SDK, GOPATH, GOENV, GOCACHE, and Virtual Envs)\n", normalizedVersion)
+		fmt.Fprintf(stderr, "✅ Successfully removed Go %s (SDK, GOPATH, GOENV, GOCACHE, and Virtual Envs)\n", normalizedVersion)
+		return nil
 	},
 }
 
-func showProgress(done chan bool) {
+func showProgress(w io.Writer, done chan bool) {
 	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	i := 0
 	ticker := time.NewTicker(80 * time.Millisecond)
@@ -135,11 +103,11 @@ func showProgress(done chan bool) {
 	for {
 		select {
 		case <-done:
-			fmt.Print("\r") // Clear the line
+			fmt.Fprint(w, "\r") // Clear the line
 			done <- true
 			return
 		case <-ticker.C:
-			fmt.Printf("\r  %s Deleting files...", frames[i%len(frames)])
+			fmt.Fprintf(w, "\r  %s Deleting files...", frames[i%len(frames)])
 			i++
 		}
 	}
@@ -1,23 +1,99 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/fun7257/vg/internal/output"
+	"github.com/fun7257/vg/internal/service"
+
 	"github.com/spf13/cobra"
 )
 
+// commandStarted is set once the arguments of the command to run have been
+// validated. Errors before that are usage errors.
+var commandStarted bool
+
 var rootCmd = &cobra.Command{
 	Use:   "vg",
 	Short: "vg is a Virtual Go environment manager",
 	Long: `vg is a Virtual Go environment manager.
 
-A Fast and Flexible Go Version Manager that helps you manage multiple Go versions per project.`,
+A Fast and Flexible Go Version Manager that helps you manage multiple Go versions per project.
+
+Diagnostics are written to standard error. vg exits with:
+  0  success
+  1  any other error
+  2  invalid command line
+  3  Go version or environment not installed
+  4  Go version or environment already exists
+  5  Go version or environment in use
+  6  download failed for network reasons
+  7  checksum mismatch
+  8  lock not acquired within --lock-timeout
+
+Files are kept in ~/.vg, or in $VG_HOME if it is set. With VG_LAYOUT=xdg
+they follow the XDG base directories instead: SDKs, archives, GOPATHs and
+virtual environments in $XDG_DATA_HOME/vg, GOCACHEs and GOMODCACHE in
+$XDG_CACHE_HOME/vg, the current links, shims and locks in $XDG_STATE_HOME/vg
+and config.toml in $XDG_CONFIG_HOME/vg.`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		commandStarted = true
+		return recoverActivation()
+	},
+}
+
+// hintError carries advice printed after the error message.
+type hintError struct {
+	err  error
+	hint string
+}
+
+func (e *hintError) Error() string {
+	return e.err.Error()
+}
+
+func (e *hintError) Unwrap() error {
+	return e.err
+}
+
+// withHint attaches hint to err.
+func withHint(err error, format string, args ...any) error {
+	return &hintError{err: err, hint: fmt.Sprintf(format, args...)}
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	if err == nil {
+		return
+	}
+	if !commandStarted {
+		err = &service.UsageError{Err: err}
+	}
+
+	code := service.ExitCode(err)
+	var exitErr *service.ExitError
+	if errors.As(err, &exitErr) && exitErr.Err == nil {
+		os.Exit(code)
+	}
+	fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+	var hint *hintError
+	if errors.As(err, &hint) {
+		fmt.Fprintf(os.Stderr, "\n%s\n", hint.hint)
+	}
+	if !commandStarted {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	// Scripts reading structured output get the error as a document too
+	if format, ferr := output.ParseFormat(outputFlag); ferr == nil && format != output.Table {
+		doc := output.Error{Header: output.NewHeader(output.KindError), Message: err.Error(), ExitCode: code}
+		if hint != nil {
+			doc.Hint = hint.hint
+		}
+		_ = writeOutput(format, doc)
 	}
+	os.Exit(code)
 }
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/service"
+	"github.com/fun7257/vg/internal/shell"
+)
+
+const (
+	// sessionVersionVar records the Go version activated in the current shell.
+	sessionVersionVar = "VG_SESSION_VERSION"
+	// sessionEnvVar records the virtual environment activated in the current shell.
+	sessionEnvVar = "VG_SESSION_ENV"
+	// sessionPathVar records the entries vg prepended to PATH so they can be
+	// removed again on the next switch.
+	sessionPathVar = "VG_SESSION_PATH"
+)
+
+// goEnv holds the Go environment of an activated version or virtual environment.
+type goEnv struct {
+	Goroot     string
+	Gopath     string
+	Gocache    string
+	Goenv      string
+	Gomodcache string
+}
+
+// gomodcacheFor returns the GOMODCACHE of the environment using gopath: the
+// shared module cache, or GOPATH/pkg/mod if the 'gomodcache' setting of c is
+// per-version.
+func gomodcacheFor(c *config.Config, gopath string) (string, error) {
+	if c.Gomodcache == config.GomodcachePerVersion {
+		return filepath.Join(gopath, "pkg", "mod"), nil
+	}
+	return config.GetGomodcacheDir()
+}
+
+// versionGoEnv returns the global Go environment of an installed version.
+func versionGoEnv(version string) (goEnv, error) {
+	c, err := config.Load()
+	if err != nil {
+		return goEnv{}, err
+	}
+	return configGoEnv(c, version)
+}
+
+// configGoEnv returns the global Go environment of an installed version
+// under configuration c.
+func configGoEnv(c *config.Config, version string) (goEnv, error) {
+	sdksDir, err := config.GetSdksDir()
+	if err != nil {
+		return goEnv{}, err
+	}
+	if !downloader.IsInstalled(sdksDir, version) {
+		return goEnv{}, &service.NotInstalledError{Version: version}
+	}
+	goroot, err := config.GetVersionGoroot(version)
+	if err != nil {
+		return goEnv{}, err
+	}
+	gopath, err := config.GetVersionGopath(version)
+	if err != nil {
+		return goEnv{}, err
+	}
+	gocache, err := config.GetVersionGocache(version)
+	if err != nil {
+		return goEnv{}, err
+	}
+	goenv, err := config.GetVersionGoenv(version)
+	if err != nil {
+		return goEnv{}, err
+	}
+	gomodcache, err := gomodcacheFor(c, gopath)
+	if err != nil {
+		return goEnv{}, err
+	}
+	return goEnv{Goroot: goroot, Gopath: gopath, Gocache: gocache, Goenv: goenv, Gomodcache: gomodcache}, nil
+}
+
+// virtualGoEnv returns the Go environment of a virtual environment.
+func virtualGoEnv(version, name string) (goEnv, error) {
+	c, err := config.Load()
+	if err != nil {
+		return goEnv{}, err
+	}
+	env, err := configGoEnv(c, version)
+	if err != nil {
+		return goEnv{}, err
+	}
+	envDir, err := config.GetEnvDir(version, name)
+	if err != nil {
+		return goEnv{}, err
+	}
+	if _, err := os.Stat(envDir); err != nil {
+		return goEnv{}, &service.NotInstalledError{Version: version, Env: name}
+	}
+	env.Gopath = filepath.Join(envDir, "gopath")
+	env.Gocache = filepath.Join(envDir, "gocache")
+	env.Goenv = filepath.Join(envDir, "goenv")
+	if env.Gomodcache, err = gomodcacheFor(c, env.Gopath); err != nil {
+		return goEnv{}, err
+	}
+	return env, nil
+}
+
+// activeVersion returns the Go version active in this shell: the session
+// version if one is set, otherwise the target of the global 'current' link.
+func activeVersion() (string, error) {
+	if version := os.Getenv(sessionVersionVar); version != "" {
+		return version, nil
+	}
+	return service.CurrentVersion()
+}
+
+// activeEnv returns the virtual environment active in this shell, or "" if
+// the active version uses its global environment. The global environment is
+// recognized by the target of the 'current' GOPATH link.
+func activeEnv() string {
+	if os.Getenv(sessionVersionVar) != "" {
+		return os.Getenv(sessionEnvVar)
+	}
+	gopathLink, err := config.GetCurrentGopathLink()
+	if err != nil {
+		return ""
+	}
+	target, err := os.Readlink(gopathLink)
+	if err != nil {
+		return ""
+	}
+	// Virtual environments live at envs/<version>/<name>/gopath
+	envDir := filepath.Dir(target)
+	envsRoot, err := config.GetEnvsDir()
+	if err != nil || filepath.Dir(filepath.Dir(envDir)) != envsRoot {
+		return ""
+	}
+	return filepath.Base(envDir)
+}
+
+// sessionPath returns PATH with the bin directories of env prepended and
+// the entries added by a previous session switch removed.
+func sessionPath(env goEnv) (prefix []string, path []string) {
+	prefix = []string{
+		filepath.Join(env.Goroot, "bin"),
+		filepath.Join(env.Gopath, "bin"),
+	}
+	return prefix, append(prefix, inheritedPath()...)
+}
+
+// inheritedPath returns the entries of PATH without those added by a
+// previous session switch.
+func inheritedPath() []string {
+	path := os.Getenv("PATH")
+	if old := os.Getenv(sessionPathVar); old != "" {
+		path = strings.TrimPrefix(path, old+string(os.PathListSeparator))
+	}
+	return filepath.SplitList(path)
+}
+
+// printSessionExports writes shell code activating env to stdout. It is
+// evaluated by the shell function installed by 'vg init'.
+func printSessionExports(version, envName string, env goEnv) error {
+	sh, err := shell.Current()
+	if err != nil {
+		return err
+	}
+	prefix, path := sessionPath(env)
+	fmt.Print(sh.RenderSession([]shell.Change{
+		shell.Set("GOROOT", env.Goroot),
+		shell.Set("GOPATH", env.Gopath),
+		shell.Set("GOCACHE", env.Gocache),
+		shell.Set("GOENV", env.Goenv),
+		shell.Set("GOMODCACHE", env.Gomodcache),
+		shell.SetPath(path),
+		shell.Set(sessionVersionVar, version),
+		shell.Set(sessionEnvVar, envName),
+		shell.Set(sessionPathVar, strings.Join(prefix, string(os.PathListSeparator))),
+	}))
+	return nil
+}
+
+// printSessionReset writes shell code that drops the session override and
+// points the environment back at the global 'current' symlinks.
+func printSessionReset() error {
+	sh, err := shell.Current()
+	if err != nil {
+		return err
+	}
+	env, err := currentLinksGoEnv()
+	if err != nil {
+		return err
+	}
+	fmt.Print(sh.RenderSession([]shell.Change{
+		shell.Set("GOROOT", env.Goroot),
+		shell.Set("GOPATH", env.Gopath),
+		shell.Set("GOCACHE", env.Gocache),
+		shell.Set("GOENV", env.Goenv),
+		shell.Set("GOMODCACHE", env.Gomodcache),
+		shell.SetPath(inheritedPath()),
+		shell.Unset(sessionVersionVar),
+		shell.Unset(sessionEnvVar),
+		shell.Unset(sessionPathVar),
+	}))
+	return nil
+}
+
+// currentLinksGoEnv returns the Go environment made of the global 'current'
+// symlinks, as exported by 'vg init'.
+func currentLinksGoEnv() (goEnv, error) {
+	goroot, err := config.GetCurrentLink()
+	if err != nil {
+		return goEnv{}, err
+	}
+	gopath, err := config.GetCurrentGopathLink()
+	if err != nil {
+		return goEnv{}, err
+	}
+	gocache, err := config.GetCurrentGocacheLink()
+	if err != nil {
+		return goEnv{}, err
+	}
+	goenv, err := config.GetCurrentGoenvLink()
+	if err != nil {
+		return goEnv{}, err
+	}
+	c, err := config.Load()
+	if err != nil {
+		return goEnv{}, err
+	}
+	gomodcache, err := gomodcacheFor(c, gopath)
+	if err != nil {
+		return goEnv{}, err
+	}
+	return goEnv{Goroot: goroot, Gopath: gopath, Gocache: gocache, Goenv: goenv, Gomodcache: gomodcache}, nil
+}
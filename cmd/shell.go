@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+
+	"github.com/spf13/cobra"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell [version]",
+	Short: "Switch the Go version of the current shell only",
+	Long: `Switch the Go version of the current shell only.
+
+Unlike 'vg use', this does not touch the global 'current' symlinks, so other
+shells and running programs keep their Go version. It prints shell code that
+the function installed by 'vg init' evaluates. Without the function, run:
+
+  eval "$(vg shell <version>)"
+
+Use --unset to return the shell to the global version.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		unset, _ := cmd.Flags().GetBool("unset")
+		if unset {
+			if err := printSessionReset(); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "✅ Returned to the global Go version in this shell")
+			return nil
+		}
+
+		if len(args) == 0 {
+			if version := os.Getenv(sessionVersionVar); version != "" {
+				fmt.Fprintf(os.Stderr, "Go %s (session)\n", version)
+			} else {
+				fmt.Fprintln(os.Stderr, "No session version set; using the global version")
+			}
+			return nil
+		}
+
+		sdksDir, err := config.GetSdksDir()
+		if err != nil {
+			return fmt.Errorf("error getting sdks dir: %w", err)
+		}
+
+		normalizedVersion, err := downloader.ResolveVersion(args[0], sdksDir)
+		if err != nil {
+			return err
+		}
+
+		env, err := versionGoEnv(normalizedVersion)
+		if err != nil {
+			return withHint(err, "Run 'vg install %s' to install this version", normalizedVersion)
+		}
+
+		if err := printSessionExports(normalizedVersion, "", env); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "✅ Switched this shell to Go %s\n", normalizedVersion)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+
+	// Keep help text out of the output evaluated by the shell function
+	shellCmd.SetOut(os.Stderr)
+	shellCmd.Flags().Bool("unset", false, "Return this shell to the global Go version")
+}
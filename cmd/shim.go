@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/project"
+	"github.com/fun7257/vg/internal/service"
+
+	"github.com/spf13/cobra"
+)
+
+// shimVersionVar selects the Go version used by shims.
+const shimVersionVar = "VG_VERSION"
+
+var shimCmd = &cobra.Command{
+	Use:    "shim <name> [args...]",
+	Short:  "Run a tool on behalf of a shim",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, env, err := shimGoEnv()
+		if err != nil {
+			return err
+		}
+		return runWithGoEnv(version, env, args, true)
+	},
+}
+
+// shimGoEnv picks the Go environment for a shim: $VG_VERSION, then the
+// version of the project, as found by 'vg use', then the global 'current'
+// symlinks. Aliases are resolved against the installed SDKs only, which
+// keeps shims off the network.
+func shimGoEnv() (string, goEnv, error) {
+	version := os.Getenv(shimVersionVar)
+	if version == "" {
+		if wd, err := os.Getwd(); err == nil {
+			version, _, _ = project.FindVersion(wd)
+		}
+	}
+	if version != "" {
+		sdksDir, err := config.GetSdksDir()
+		if err != nil {
+			return "", goEnv{}, err
+		}
+		version = downloader.ResolveInstalled(version, sdksDir)
+		env, err := versionGoEnv(version)
+		if err != nil {
+			return "", goEnv{}, withHint(err, "Run 'vg install %s' to install it", version)
+		}
+		return version, env, nil
+	}
+
+	// Fall back to the global selection, including a loaded virtual environment
+	env, err := currentLinksGoEnv()
+	if err != nil {
+		return "", goEnv{}, err
+	}
+	target, err := os.Readlink(env.Goroot)
+	if err != nil {
+		return "", goEnv{}, withHint(service.ErrNoActiveVersion, "Run 'vg use <version>' to activate one")
+	}
+	return filepath.Base(target), env, nil
+}
+
+func init() {
+	rootCmd.AddCommand(shimCmd)
+
+	// Every argument belongs to the shimmed tool
+	shimCmd.DisableFlagParsing = true
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/output"
 
 	"github.com/spf13/cobra"
 )
@@ -14,12 +15,19 @@ import (
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show current Go version and environment status",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat()
+		if err != nil {
+			return err
+		}
+		if format != output.Table {
+			return writeOutput(format, statusDocument())
+		}
+
 		// 1. Get Go Version (from 'current' symlink)
 		currentLink, err := config.GetCurrentLink()
 		if err != nil {
-			fmt.Printf("Error getting current link: %v\n", err)
-			return
+			return fmt.Errorf("error getting current link: %w", err)
 		}
 
 		targetGoroot, err := os.Readlink(currentLink)
@@ -69,14 +77,62 @@ var statusCmd = &cobra.Command{
 			}
 		}
 
+		// Shells switched with 'vg shell' or '--session' override the symlinks
+		if sessionVersion := os.Getenv(sessionVersionVar); sessionVersion != "" {
+			sessionEnv := os.Getenv(sessionEnvVar)
+			if sessionEnv == "" {
+				sessionEnv = "(global)"
+			}
+			fmt.Printf("Session:     Go %s, environment %s (this shell only)\n", sessionVersion, sessionEnv)
+		}
+
 		fmt.Println()
 		fmt.Printf("GOROOT:      %s\n", targetGoroot)
 		fmt.Printf("GOPATH:      %s\n", targetGopath)
 		fmt.Printf("GOCACHE:     %s\n", targetGocache)
 		fmt.Printf("GOENV:       %s\n", targetGoenv)
+		return nil
 	},
 }
 
+// statusDocument describes the version and environment active in this
+// shell, taking 'vg shell' and '--session' switches into account.
+func statusDocument() output.Status {
+	doc := output.Status{Header: output.NewHeader(output.KindStatus)}
+	version, err := activeVersion()
+	if err != nil {
+		return doc
+	}
+	doc.Active = &output.Active{
+		Version: version,
+		Env:     activeEnv(),
+		Session: os.Getenv(sessionVersionVar) != "",
+	}
+
+	var env goEnv
+	if doc.Active.Env != "" {
+		env, err = virtualGoEnv(version, doc.Active.Env)
+		if envDir, err := config.GetEnvDir(version, doc.Active.Env); err == nil {
+			if data, err := os.ReadFile(filepath.Join(envDir, "remark.txt")); err == nil {
+				doc.Remark = strings.TrimSpace(string(data))
+			}
+		}
+	} else {
+		env, err = versionGoEnv(version)
+	}
+	if err != nil {
+		return doc
+	}
+	doc.Paths = &output.Paths{
+		Goroot:     env.Goroot,
+		Gopath:     env.Gopath,
+		Gocache:    env.Gocache,
+		Goenv:      env.Goenv,
+		Gomodcache: env.Gomodcache,
+	}
+	return doc
+}
+
 func init() {
 	rootCmd.AddCommand(statusCmd)
 }
@@ -1,13 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/fun7257/vg/internal/config"
 	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+	"github.com/fun7257/vg/internal/project"
+	"github.com/fun7257/vg/internal/service"
 
 	"github.com/spf13/cobra"
 )
@@ -15,159 +17,168 @@ import (
 var useCmd = &cobra.Command{
 	Use:   "use [version]",
 	Short: "Switch to a specific Go version",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		version := args[0]
-		// Normalize version (remove 'go' prefix if present)
-		normalizedVersion := strings.TrimPrefix(version, "go")
-
-		sdksDir, err := config.GetSdksDir()
-		if err != nil {
-			fmt.Printf("Error getting sdks dir: %v\n", err)
-			os.Exit(1)
+	Long: `Switch to a specific Go version.
+
+The version may be exact (e.g. 1.25.4) or an alias such as latest, 1.23
+or 1.23rc. See 'vg install --help' for details.
+
+Without an argument, the version is read from the nearest .go-version,
+.tool-versions or go.mod (toolchain or go directive), searching upwards from
+the current directory, or else taken from the 'default_version' setting.
+
+The 'auto_install' setting decides what happens to a missing SDK: with
+"prompt" (the default) a version pinned by the project is installed and
+you are asked about any other, "always" installs it without asking and
+"never" fails. See 'vg config --help'. --yes (or --install) and --no-install
+override the setting.
+
+vg never waits for an answer that cannot come: if standard input is not a
+terminal, or VG_NONINTERACTIVE is set, a question about installing fails
+right away. Pass --yes in CI and scripts to install missing versions.
+
+SDKs installed for another platform with 'vg install --os/--arch' are only
+activated with --force.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		noInstall, _ := cmd.Flags().GetBool("no-install")
+		for _, name := range []string{"yes", "install"} {
+			if set, _ := cmd.Flags().GetBool(name); set && noInstall {
+				return fmt.Errorf("--%s and --no-install cannot be used together", name)
+			}
 		}
-
-		vgHome, err := config.GetVgHome()
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("Error getting vg home: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-
-		// Check if version exists
-		versionPath := filepath.Join(sdksDir, normalizedVersion)
-		if _, err := os.Stat(versionPath); os.IsNotExist(err) {
-			// Try to install it
-			fmt.Printf("Go version %s is not installed. Do you want to install it? [y/N] ", normalizedVersion)
-
-			var response string
-			_, err := fmt.Scanln(&response)
-			if err != nil && err.Error() != "unexpected newline" {
-				fmt.Printf("Error scanning response: %v\n", err)
-				os.Exit(1)
-			}
-
-			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
-				fmt.Println("\nRun 'vg list' to see installed versions")
-				fmt.Printf("Run 'vg install %s' to install this version\n", version)
-				os.Exit(1)
-			}
-
-			distsDir, err := config.GetDistsDir()
+		// Versions pinned by the project are installed without asking
+		autoInstall := cfg.AutoInstall == config.AutoInstallAlways
+		yes, _ := cmd.Flags().GetBool("yes")
+		install, _ := cmd.Flags().GetBool("install")
+		noInstall, _ := cmd.Flags().GetBool("no-install")
+
+		var version string
+		if len(args) == 1 {
+			version = args[0]
+		} else {
+			wd, err := os.Getwd()
 			if err != nil {
-				fmt.Printf("Error getting dists dir: %v\n", err)
-				os.Exit(1)
+				return fmt.Errorf("error getting working directory: %w", err)
 			}
-
-			// Call downloader
-			if err := downloader.DownloadAndInstall(normalizedVersion, distsDir, sdksDir); err != nil {
-				fmt.Printf("❌ Failed to install Go %s: %v\n", normalizedVersion, err)
-				os.Exit(1)
+			detected, source, err := project.FindVersion(wd)
+			switch {
+			case err == nil:
+				fmt.Printf("Found Go %s in %s\n", detected, source)
+				version = detected
+				autoInstall = cfg.AutoInstall != config.AutoInstallNever
+			case errors.Is(err, project.ErrNoVersion) && cfg.DefaultVersion != "":
+				fmt.Printf("Using default Go %s\n", cfg.DefaultVersion)
+				version = cfg.DefaultVersion
+			default:
+				return withHint(err, "Run 'vg use <version>' or 'vg local <version>' to pin one")
 			}
-
-			fmt.Printf("✅ Automatically installed Go %s\n", normalizedVersion)
-
 		}
 
-		// Get paths for this version
-		gopath, err := config.GetVersionGopath(normalizedVersion)
+		sdksDir, err := config.GetSdksDir()
 		if err != nil {
-			fmt.Printf("Error getting gopath: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting sdks dir: %w", err)
 		}
 
-		goenvPath, err := config.GetVersionGoenv(normalizedVersion)
+		// Normalize version (remove 'go' prefix, resolve aliases like 'latest')
+		normalizedVersion, err := resolveVersion(cmd.ErrOrStderr(), version, sdksDir)
 		if err != nil {
-			fmt.Printf("Error getting goenv path: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 
-		gocache, err := config.GetVersionGocache(normalizedVersion)
+		// Keep the version from being installed or removed concurrently
+		sdkLock, err := acquireLock(lock.SDK(normalizedVersion))
 		if err != nil {
-			fmt.Printf("Error getting gocache: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-
-		// Verify that GOPATH exists for this version (legacy check)
-		if _, err := os.Stat(gopath); os.IsNotExist(err) {
-			// Create GOPATH if it doesn't exist (for versions installed before this feature)
-			if err := os.MkdirAll(gopath, 0755); err != nil {
-				fmt.Printf("Error creating gopath: %v\n", err)
-				os.Exit(1)
+		defer func() {
+			_ = sdkLock.Release()
+		}()
+
+		// SDKs for other platforms cannot run here
+		if number, platform := downloader.SplitSDKName(normalizedVersion); !platform.IsNative() {
+			if force, _ := cmd.Flags().GetBool("force"); !force {
+				return withHint(fmt.Errorf("Go %s is built for %s and cannot run on %s", normalizedVersion, platform, downloader.NativePlatform()),
+					"Use --force to activate it anyway")
 			}
-			for _, subdir := range []string{"src", "bin", "pkg"} {
-				if err := os.MkdirAll(filepath.Join(gopath, subdir), 0755); err != nil {
-					fmt.Printf("Error creating gopath subdirectory %s: %v\n", subdir, err)
-					os.Exit(1)
-				}
+			if !downloader.IsInstalled(sdksDir, normalizedVersion) {
+				return withHint(&service.NotInstalledError{Version: normalizedVersion},
+					"Run 'vg install --os %s --arch %s %s' to install it", platform.OS, platform.Arch, number)
 			}
 		}
 
-		// Verify that GOENV exists for this version
-		if _, err := os.Stat(goenvPath); os.IsNotExist(err) {
-			// Create GOENV if it doesn't exist
-			goenvsDir := filepath.Dir(goenvPath)
-			if err := os.MkdirAll(goenvsDir, 0755); err != nil {
-				fmt.Printf("Error creating goenvs directory: %v\n", err)
-				os.Exit(1)
-			}
-			goenvContent := "# This file is managed by vg.\n# GOROOT and GOPATH are set automatically by 'vg init'.\n# You can add custom environment variables below or use 'go env -w KEY=VALUE'\n"
-			if err := os.WriteFile(goenvPath, []byte(goenvContent), 0644); err != nil {
-				fmt.Printf("Error creating goenv file: %v\n", err)
-				os.Exit(1)
+		// Check if version exists
+		if !downloader.IsInstalled(sdksDir, normalizedVersion) {
+			notInstalled := &service.NotInstalledError{Version: normalizedVersion}
+			switch {
+			case yes || install:
+				// Install without asking
+			case noInstall || cfg.AutoInstall == config.AutoInstallNever:
+				return withHint(notInstalled, "Run 'vg install %s' to install this version", version)
+			case !autoInstall:
+				ok, err := confirm(fmt.Sprintf("Go version %s is not installed. Do you want to install it?", normalizedVersion))
+				if errors.Is(err, errNoPrompt) {
+					return withHint(fmt.Errorf("%w (%v)", notInstalled, err),
+						"Pass --yes to install it or run 'vg install %s' first", version)
+				}
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return withHint(notInstalled,
+						"Run 'vg list' to see installed versions\nRun 'vg install %s' to install this version", version)
+				}
 			}
-		}
 
-		// Verify that GOCACHE exists for this version
-		if _, err := os.Stat(gocache); os.IsNotExist(err) {
-			// Create GOCACHE if it doesn't exist
-			if err := os.MkdirAll(gocache, 0755); err != nil {
-				fmt.Printf("Error creating gocache: %v\n", err)
-				os.Exit(1)
+			distsDir, err := config.GetDistsDir()
+			if err != nil {
+				return fmt.Errorf("error getting dists dir: %w", err)
 			}
-		}
 
-		// Helper function to update a symlink
-		updateSymlink := func(linkPath, targetPath string, linkName string) error {
-			// Remove existing symlink if it exists
-			if _, err := os.Lstat(linkPath); err == nil {
-				if err := os.Remove(linkPath); err != nil {
-					return fmt.Errorf("error removing old %s symlink: %w", linkName, err)
-				}
-			}
-			// Create new symlink
-			if err := os.Symlink(targetPath, linkPath); err != nil {
-				return fmt.Errorf("error creating %s symlink: %w", linkName, err)
+			// Call downloader
+			installer := downloader.NewInstaller()
+			installer.Config = cfg
+			if err := service.Install(installer, normalizedVersion, distsDir, sdksDir); err != nil {
+				return fmt.Errorf("failed to install Go %s: %w", normalizedVersion, err)
 			}
-			return nil
-		}
 
-		// Update all symlinks
-		currentLink, _ := config.GetCurrentLink()
-		if err := updateSymlink(currentLink, versionPath, "current"); err != nil {
-			fmt.Printf("❌ %v\n", err)
-			os.Exit(1)
+			fmt.Printf("✅ Automatically installed Go %s\n", normalizedVersion)
 		}
 
-		if err := updateSymlink(filepath.Join(vgHome, "current-gopath"), gopath, "current-gopath"); err != nil {
-			fmt.Printf("❌ %v\n", err)
-			os.Exit(1)
-		}
+		// Record the check of SDKs installed before markers existed, now that
+		// we hold their lock
+		_ = downloader.MarkInstalled(sdksDir, normalizedVersion)
 
-		if err := updateSymlink(filepath.Join(vgHome, "current-gocache"), gocache, "current-gocache"); err != nil {
-			fmt.Printf("❌ %v\n", err)
-			os.Exit(1)
+		// Switch all symlinks together
+		activationLock, err := acquireLock(lock.Activation)
+		if err != nil {
+			return err
 		}
-
-		if err := updateSymlink(filepath.Join(vgHome, "current-goenv"), goenvPath, "current-goenv"); err != nil {
-			fmt.Printf("❌ %v\n", err)
-			os.Exit(1)
+		defer func() {
+			_ = activationLock.Release()
+		}()
+		if err := service.Use(sdksDir, normalizedVersion); err != nil {
+			return err
 		}
 
+		refreshShims()
+
 		fmt.Printf("✅ Switched to Go %s\n", normalizedVersion)
 		fmt.Println("\nEnvironment variables will be updated automatically via symlinks.")
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(useCmd)
+
+	useCmd.Flags().Bool("force", false, "Activate an SDK built for another platform")
+	useCmd.Flags().BoolP("yes", "y", false, "Install the version without asking if it is missing")
+	useCmd.Flags().Bool("install", false, "Same as --yes")
+	useCmd.Flags().Bool("no-install", false, "Fail instead of installing a missing version")
 }
@@ -164,7 +164,10 @@ install_binary() {
 
 # Check if this is first time using vg
 is_first_time() {
-    local vg_home="${HOME}/.vg"
+    local vg_home="${VG_HOME:-${HOME}/.vg}"
+    if [ "${VG_LAYOUT:-}" = "xdg" ]; then
+        vg_home="${XDG_DATA_HOME:-${HOME}/.local/share}/vg"
+    fi
     local sdks_dir="${vg_home}/sdks"
     
     # Check if .vg directory doesn't exist
@@ -0,0 +1,223 @@
+// Package activation switches the global 'current*' symlinks that select the
+// active Go version and virtual environment.
+//
+// Each link is replaced atomically by creating a temporary symlink next to it
+// and renaming it over the old one. The links are switched as a group: if one
+// of them fails, the ones already switched are restored. A journal written
+// before the first rename lets an interrupted switch be completed by the next
+// vg invocation (see Interrupted and Recover), so a crash never leaves GOROOT
+// and GOPATH pointing at different versions for longer than that.
+package activation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+// journalName is the file recording a switch in progress, relative to the state directory.
+const journalName = ".activation.json"
+
+// Targets are the paths the 'current*' symlinks should point to. An empty
+// field leaves the corresponding link untouched.
+type Targets struct {
+	Goroot  string `json:"goroot,omitempty"`
+	Gopath  string `json:"gopath,omitempty"`
+	Gocache string `json:"gocache,omitempty"`
+	Goenv   string `json:"goenv,omitempty"`
+}
+
+// link is a single symlink to switch.
+type link struct {
+	name   string
+	path   string
+	target string
+
+	// previous target, if the link existed before the switch
+	old     string
+	existed bool
+}
+
+// Switch points the 'current*' symlinks at targets. Either all links are
+// switched or, on error, all of them are left as they were. Callers must
+// hold the activation lock.
+func Switch(targets Targets) error {
+	if err := Recover(); err != nil {
+		return err
+	}
+
+	links, err := resolveLinks(targets)
+	if err != nil {
+		return err
+	}
+	for i := range links {
+		if old, err := os.Readlink(links[i].path); err == nil {
+			links[i].old = old
+			links[i].existed = true
+		}
+	}
+
+	journal, err := journalPath()
+	if err != nil {
+		return err
+	}
+	if err := writeJournal(journal, targets); err != nil {
+		return fmt.Errorf("error writing activation journal: %w", err)
+	}
+
+	// Hold off Ctrl-C and termination until the links are consistent
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer func() {
+		signal.Stop(signals)
+		select {
+		case <-signals:
+			os.Exit(130)
+		default:
+		}
+	}()
+
+	for i, l := range links {
+		if err := replaceSymlink(l.path, l.target); err != nil {
+			rollback(links[:i])
+			_ = os.Remove(journal)
+			return fmt.Errorf("error switching %s symlink: %w", l.name, err)
+		}
+	}
+
+	return os.Remove(journal)
+}
+
+// Interrupted reports whether a switch was interrupted and Recover needs to
+// run. It is cheap enough to be called by every command.
+func Interrupted() bool {
+	journal, err := journalPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(journal)
+	return err == nil
+}
+
+// Recover completes a switch that was interrupted by a crash, if any.
+// Callers must hold the activation lock.
+func Recover() error {
+	journal, err := journalPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(journal)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var targets Targets
+	if err := json.Unmarshal(data, &targets); err != nil {
+		// A torn journal means no link had been touched yet
+		return os.Remove(journal)
+	}
+
+	links, err := resolveLinks(targets)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		if err := replaceSymlink(l.path, l.target); err != nil {
+			return fmt.Errorf("error completing interrupted switch of %s symlink: %w", l.name, err)
+		}
+	}
+	return os.Remove(journal)
+}
+
+// resolveLinks returns the links to switch for targets, in a fixed order.
+func resolveLinks(targets Targets) ([]link, error) {
+	var links []link
+	for _, l := range []struct {
+		name   string
+		target string
+		path   func() (string, error)
+	}{
+		{"current", targets.Goroot, config.GetCurrentLink},
+		{"current-gopath", targets.Gopath, config.GetCurrentGopathLink},
+		{"current-gocache", targets.Gocache, config.GetCurrentGocacheLink},
+		{"current-goenv", targets.Goenv, config.GetCurrentGoenvLink},
+	} {
+		if l.target == "" {
+			continue
+		}
+		path, err := l.path()
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link{name: l.name, path: path, target: l.target})
+	}
+	return links, nil
+}
+
+// rollback restores links to their previous targets.
+func rollback(links []link) {
+	for _, l := range links {
+		if l.existed {
+			_ = replaceSymlink(l.path, l.old)
+		} else {
+			_ = os.Remove(l.path)
+		}
+	}
+}
+
+// symlink and rename create and move symlinks. Tests replace them to inject
+// failures partway through a switch.
+var (
+	symlink = os.Symlink
+	rename  = os.Rename
+)
+
+// replaceSymlink atomically points the symlink at path to target.
+func replaceSymlink(path, target string) error {
+	tmp := fmt.Sprintf("%s.tmp-%d-%d", path, os.Getpid(), rand.Int())
+	if err := symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeJournal atomically writes the journal recording targets.
+func writeJournal(path string, targets Targets) error {
+	data, err := json.Marshal(targets)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+	return nil
+}
+
+func journalPath() (string, error) {
+	layout, err := config.ResolveLayout()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(layout.State, journalName), nil
+}
@@ -0,0 +1,169 @@
+package activation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+// setup points vg home at a temporary directory and returns it.
+func setup(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv(config.HomeEnvVar, home)
+	t.Setenv(config.LayoutEnvVar, "")
+	return home
+}
+
+func targetsFor(version string) Targets {
+	return Targets{
+		Goroot:  "/sdks/" + version,
+		Gopath:  "/gopaths/" + version,
+		Gocache: "/gocaches/" + version,
+		Goenv:   "/goenvs/" + version + ".env",
+	}
+}
+
+// readLinks returns the targets of the 'current*' links, "" for missing ones.
+func readLinks(t *testing.T, home string) Targets {
+	t.Helper()
+	read := func(name string) string {
+		target, _ := os.Readlink(filepath.Join(home, name))
+		return target
+	}
+	return Targets{
+		Goroot:  read("current"),
+		Gopath:  read("current-gopath"),
+		Gocache: read("current-gocache"),
+		Goenv:   read("current-goenv"),
+	}
+}
+
+// failRenameAt makes the nth call to rename fail (1-based); other calls
+// succeed.
+func failRenameAt(t *testing.T, n int) {
+	t.Helper()
+	calls := 0
+	rename = func(oldpath, newpath string) error {
+		calls++
+		if calls == n {
+			_ = os.Remove(oldpath)
+			return errors.New("injected failure")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	t.Cleanup(func() { rename = os.Rename })
+}
+
+func TestSwitch(t *testing.T) {
+	home := setup(t)
+	if err := Switch(targetsFor("1.22.0")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readLinks(t, home), targetsFor("1.22.0"); got != want {
+		t.Errorf("links = %+v, want %+v", got, want)
+	}
+	if Interrupted() {
+		t.Error("journal left behind after a successful switch")
+	}
+}
+
+func TestSwitchRollsBackAfterFailure(t *testing.T) {
+	for failAt := 1; failAt <= 4; failAt++ {
+		t.Run(fmt.Sprintf("link %d", failAt), func(t *testing.T) {
+			home := setup(t)
+			if err := Switch(targetsFor("1.22.0")); err != nil {
+				t.Fatal(err)
+			}
+
+			failRenameAt(t, failAt)
+			if err := Switch(targetsFor("1.23.0")); err == nil {
+				t.Fatalf("switch with rename %d failing succeeded", failAt)
+			}
+			if got, want := readLinks(t, home), targetsFor("1.22.0"); got != want {
+				t.Errorf("failure at link %d: links = %+v, want %+v", failAt, got, want)
+			}
+			if Interrupted() {
+				t.Error("journal left behind after a rolled back switch")
+			}
+		})
+	}
+}
+
+func TestSwitchRollsBackNewLinks(t *testing.T) {
+	home := setup(t)
+	failRenameAt(t, 3)
+	if err := Switch(targetsFor("1.22.0")); err == nil {
+		t.Fatal("switch succeeded")
+	}
+	if got := readLinks(t, home); got != (Targets{}) {
+		t.Errorf("links = %+v, want none", got)
+	}
+}
+
+func TestRecoverCompletesJournaledSwitch(t *testing.T) {
+	home := setup(t)
+	if err := Switch(targetsFor("1.22.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash after the journal was written and two links were switched
+	journal, err := journalPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeJournal(journal, targetsFor("1.23.0")); err != nil {
+		t.Fatal(err)
+	}
+	partial := targetsFor("1.23.0")
+	partial.Gocache, partial.Goenv = "", ""
+	links, err := resolveLinks(partial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range links {
+		if err := replaceSymlink(l.path, l.target); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !Interrupted() {
+		t.Fatal("Interrupted() = false with a journal present")
+	}
+	if err := Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readLinks(t, home), targetsFor("1.23.0"); got != want {
+		t.Errorf("links = %+v, want %+v", got, want)
+	}
+	if Interrupted() {
+		t.Error("journal left behind after recovery")
+	}
+}
+
+func TestRecoverDiscardsTornJournal(t *testing.T) {
+	home := setup(t)
+	if err := Switch(targetsFor("1.22.0")); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := journalPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(journal, []byte(`{"goroot":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readLinks(t, home), targetsFor("1.22.0"); got != want {
+		t.Errorf("links = %+v, want %+v", got, want)
+	}
+	if Interrupted() {
+		t.Error("torn journal not removed")
+	}
+}
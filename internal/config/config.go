@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Origin tells where the effective value of a setting comes from.
+type Origin string
+
+const (
+	OriginDefault Origin = "default"
+	OriginFile    Origin = "file"
+	OriginEnv     Origin = "env"
+)
+
+// Value is the effective value of a setting.
+type Value struct {
+	Key *Key
+	// Values holds the value, one element unless Key is a list. It is empty
+	// if the setting is unset and has no default.
+	Values []string
+	Origin Origin
+}
+
+// String returns the value as it is written in environment variables.
+func (v Value) String() string {
+	return strings.Join(v.Values, ",")
+}
+
+// Lookup returns the effective value of k: its environment variable, then
+// the configuration file, then the default.
+func (s Settings) Lookup(k *Key) Value {
+	if env := os.Getenv(k.EnvVar); env != "" {
+		values := []string{env}
+		if k.Kind == KindList {
+			values = nil
+			for _, v := range strings.Split(env, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+		return Value{Key: k, Values: values, Origin: OriginEnv}
+	}
+	if values := s.Strings(k.Name); len(values) > 0 {
+		return Value{Key: k, Values: values, Origin: OriginFile}
+	}
+	var values []string
+	if k.Default != "" {
+		values = []string{k.Default}
+	}
+	return Value{Key: k, Values: values, Origin: OriginDefault}
+}
+
+// Config is the typed configuration, read from the configuration file and
+// the environment variables overriding it.
+type Config struct {
+	// Mirrors are the download base URLs, empty for the default.
+	Mirrors        []string
+	AutoInstall    AutoInstall
+	DefaultVersion string
+	Gomodcache     GomodcacheMode
+	Download       DownloadConfig
+}
+
+// DownloadConfig holds the settings of the [download] table.
+type DownloadConfig struct {
+	Checksum ChecksumPolicy
+	// Retries and RetryDelay are nil if unset.
+	Retries    *int
+	RetryDelay *time.Duration
+	Source     string
+	// Gosum is the go.sum file pinning toolchain hashes, "" if unset.
+	Gosum string
+}
+
+// Load reads the configuration and validates its values.
+func Load() (*Config, error) {
+	settings, err := LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every value is validated, so those below parse
+	values := map[string]Value{}
+	for _, k := range Keys {
+		v := settings.Lookup(k)
+		for _, s := range v.Values {
+			if err := k.Validate(s); err != nil {
+				if v.Origin == OriginEnv {
+					return nil, fmt.Errorf("%s: %w", k.EnvVar, err)
+				}
+				path, _ := GetConfigFile()
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		values[k.Name] = v
+	}
+	// set returns the value of an explicitly set key, or "".
+	set := func(name string) string {
+		if v := values[name]; v.Origin != OriginDefault {
+			return v.String()
+		}
+		return ""
+	}
+
+	c := &Config{
+		AutoInstall:    AutoInstall(values[AutoInstallSetting].String()),
+		DefaultVersion: values[DefaultVersionSetting].String(),
+		Gomodcache:     GomodcacheMode(values[GomodcacheSetting].String()),
+		Download: DownloadConfig{
+			Checksum: ChecksumPolicy(values[ChecksumSetting].String()),
+			Source:   values[SourceSetting].String(),
+			Gosum:    values[GosumSetting].String(),
+		},
+	}
+	if v := values[MirrorSetting]; v.Origin != OriginDefault {
+		c.Mirrors = v.Values
+	}
+	if s := set(RetriesSetting); s != "" {
+		n, _ := strconv.Atoi(s)
+		c.Download.Retries = &n
+	}
+	if s := set(RetryDelaySetting); s != "" {
+		d, _ := time.ParseDuration(s)
+		c.Download.RetryDelay = &d
+	}
+	return c, nil
+}
@@ -0,0 +1,371 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ConfigFileName is the vg configuration file inside the config
+	// directory of the layout.
+	ConfigFileName = "config.toml"
+)
+
+// GetConfigFile returns the path to the vg configuration file
+func GetConfigFile() (string, error) {
+	layout, err := ResolveLayout()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(layout.Config, ConfigFileName), nil
+}
+
+// Settings holds the raw key/value pairs of the configuration file. Keys
+// inside a [table] are prefixed with the table name and a dot. Arrays are
+// stored as []string, all other values as string.
+type Settings map[string]any
+
+// LoadSettings reads the configuration file. A missing file yields empty
+// settings.
+func LoadSettings() (Settings, error) {
+	path, err := GetConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return Settings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	settings, err := parseSettings(bufio.NewScanner(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// String returns the value of key, or "" if it is unset or not a string.
+func (s Settings) String(key string) string {
+	v, _ := s[key].(string)
+	return v
+}
+
+// Strings returns the value of key as a list. A single string is returned
+// as a one-element list.
+func (s Settings) Strings(key string) []string {
+	switch v := s[key].(type) {
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	}
+	return nil
+}
+
+// parseSettings parses the subset of TOML used by vg: key/value pairs with
+// string, boolean, integer and string array values, and [table] headers.
+func parseSettings(scanner *bufio.Scanner) (Settings, error) {
+	settings := Settings{}
+	table := ""
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+
+		// Arrays may span several lines
+		for strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") && scanner.Scan() {
+			lineNo++
+			value += " " + strings.TrimSpace(stripComment(scanner.Text()))
+		}
+
+		if table != "" {
+			key = table + "." + key
+		}
+
+		parsed, err := parseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		settings[key] = parsed
+	}
+	return settings, scanner.Err()
+}
+
+func parseValue(value string) (any, error) {
+	if strings.HasPrefix(value, "[") {
+		if !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		var list []string
+		for _, item := range splitArray(value[1 : len(value)-1]) {
+			s, err := parseScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return parseScalar(value)
+}
+
+func parseScalar(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	case value == "":
+		return "", fmt.Errorf("missing value")
+	}
+	// Booleans, numbers and durations are kept as their literal text
+	return value, nil
+}
+
+// splitArray splits the inside of an array on commas outside quotes.
+func splitArray(s string) []string {
+	var items []string
+	var cur strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			if item := strings.TrimSpace(cur.String()); item != "" {
+				items = append(items, item)
+			}
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if item := strings.TrimSpace(cur.String()); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+// stripComment removes a trailing # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// SetSetting writes a setting to the configuration file, replacing its
+// current value and creating the file if needed. A list is written as an
+// array. Comments and the other settings are preserved, except comments
+// inside a replaced multi-line array.
+func SetSetting(k *Key, values []string) error {
+	path, err := GetConfigFile()
+	if err != nil {
+		return err
+	}
+	lines, err := readLines(path)
+	if err != nil {
+		return err
+	}
+
+	table, name := k.Table()
+	line := name + " = " + FormatValue(k, values)
+	if start, end, found := findSetting(lines, k.Name); found {
+		// Keep the comment of a single-line setting
+		if end-start == 1 {
+			if comment := strings.TrimPrefix(lines[start], stripComment(lines[start])); comment != "" {
+				line += " " + comment
+			}
+		}
+		lines = slices.Replace(lines, start, end, line)
+		return writeLines(path, lines)
+	}
+
+	// Append to the table, after its last setting: blank lines and comments
+	// at its end belong to whatever follows
+	start, end, found := findTable(lines, table)
+	if !found {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+table+"]")
+		start, end = len(lines), len(lines)
+	}
+	at := end
+	for at > start && strings.TrimSpace(stripComment(lines[at-1])) == "" {
+		at--
+	}
+	lines = slices.Insert(lines, at, line)
+	return writeLines(path, lines)
+}
+
+// UnsetSetting removes a setting from the configuration file. It reports
+// whether the setting was present.
+func UnsetSetting(k *Key) (bool, error) {
+	path, err := GetConfigFile()
+	if err != nil {
+		return false, err
+	}
+	lines, err := readLines(path)
+	if err != nil {
+		return false, err
+	}
+	start, end, found := findSetting(lines, k.Name)
+	if !found {
+		return false, nil
+	}
+	return true, writeLines(path, slices.Delete(lines, start, end))
+}
+
+// FormatValue formats values of k as a TOML value.
+func FormatValue(k *Key, values []string) string {
+	switch k.Kind {
+	case KindList:
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = strconv.Quote(v)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	case KindInt:
+		return values[0]
+	}
+	return strconv.Quote(values[0])
+}
+
+// findSetting returns the lines [start, end) holding key, which may span
+// several lines for arrays.
+func findSetting(lines []string, key string) (start, end int, found bool) {
+	table := ""
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		start = i
+		value = strings.TrimSpace(value)
+		for strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+		name = strings.Trim(strings.TrimSpace(name), `"`)
+		if table != "" {
+			name = table + "." + name
+		}
+		if name == key {
+			return start, i + 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// findTable returns the lines [start, end) of a table's body. The top-level
+// table "" ends at the first table header.
+func findTable(lines []string, table string) (start, end int, found bool) {
+	current := ""
+	found = table == ""
+	for i, line := range lines {
+		line = strings.TrimSpace(stripComment(line))
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") || strings.Contains(line, "=") {
+			continue
+		}
+		if found {
+			return start, i, true
+		}
+		current = strings.TrimSpace(line[1 : len(line)-1])
+		if current == table {
+			start, found = i+1, true
+		}
+	}
+	return start, len(lines), found
+}
+
+// readLines returns the lines of the file at path, none if it is missing.
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), nil
+}
+
+// writeLines replaces the file at path with lines.
+func writeLines(path string, lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Join(err, os.Remove(tmp))
+	}
+	return nil
+}
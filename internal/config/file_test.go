@@ -0,0 +1,310 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setup points the configuration at a temporary vg home and returns the
+// path of the configuration file.
+func setup(t *testing.T) string {
+	t.Helper()
+	t.Setenv(HomeEnvVar, t.TempDir())
+	t.Setenv(LayoutEnvVar, "")
+	for _, k := range Keys {
+		t.Setenv(k.EnvVar, "")
+	}
+	path, err := GetConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want Settings
+	}{
+		{
+			name: "strings",
+			toml: `a = "plain"
+b = 'literal \n'
+c = "escaped \"quote\" \\ \t"
+d = ""`,
+			want: Settings{"a": "plain", "b": `literal \n`, "c": "escaped \"quote\" \\ \t", "d": ""},
+		},
+		{
+			name: "comments and quoted hash",
+			toml: `# leading comment
+a = "x#y" # trailing comment
+b = 'p#q'   # another
+c = "\"#\"" #`,
+			want: Settings{"a": "x#y", "b": "p#q", "c": `"#"`},
+		},
+		{
+			name: "literals",
+			toml: `retries = 3
+delay = 2s
+flag = true`,
+			want: Settings{"retries": "3", "delay": "2s", "flag": "true"},
+		},
+		{
+			name: "arrays",
+			toml: `a = ["x", "y,z", 'w#v']
+b = []
+c = [ "one" , ]
+"quoted" = ["q"]`,
+			want: Settings{"a": []string{"x", "y,z", "w#v"}, "b": []string(nil), "c": []string{"one"}, "quoted": []string{"q"}},
+		},
+		{
+			name: "multi-line array",
+			toml: `mirror = [
+  "https://a.example/", # first
+  "https://b.example/",
+]
+after = "x"`,
+			want: Settings{"mirror": []string{"https://a.example/", "https://b.example/"}, "after": "x"},
+		},
+		{
+			name: "tables",
+			toml: `top = "t"
+
+[download]
+checksum = "warn"
+[ other ] # comment
+key = "v"`,
+			want: Settings{"top": "t", "download.checksum": "warn", "other.key": "v"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSettings(bufio.NewScanner(strings.NewReader(tt.toml)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSettings =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSettingsErrors(t *testing.T) {
+	for _, toml := range []string{
+		`no equals sign`,
+		`a = "unterminated`,
+		`a = 'unterminated`,
+		`a = ["unterminated"`,
+		`a =`,
+		`a = ["x", "unterminated]`,
+	} {
+		if got, err := parseSettings(bufio.NewScanner(strings.NewReader(toml))); err == nil {
+			t.Errorf("parseSettings(%q) = %v, want error", toml, got)
+		}
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	for line, want := range map[string]string{
+		`a = "b" # c`:        `a = "b" `,
+		`a = "b#c"`:          `a = "b#c"`,
+		`a = 'b#c' # d`:      `a = 'b#c' `,
+		`a = "b\"#c" # d`:    `a = "b\"#c" `,
+		`a = 'b\' # c`:       `a = 'b\' `,
+		`# whole line`:       ``,
+		`a = ["x#", 'y#'] #`: `a = ["x#", 'y#'] `,
+	} {
+		if got := stripComment(line); got != want {
+			t.Errorf("stripComment(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestSplitArray(t *testing.T) {
+	for s, want := range map[string][]string{
+		`"a", "b"`:        {`"a"`, `"b"`},
+		`"a,b", 'c,d'`:    {`"a,b"`, `'c,d'`},
+		`"a\",b", "c"`:    {`"a\",b"`, `"c"`},
+		` "a" , , "b" , `: {`"a"`, `"b"`},
+		``:                nil,
+	} {
+		if got := splitArray(s); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitArray(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestSetAndUnsetSetting(t *testing.T) {
+	path := setup(t)
+	const original = `# vg configuration
+mirror = [
+  "https://old.example/", # keep me?
+]
+
+# Retry settings
+[download]
+retries = 5 # plenty
+`
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name  string
+		apply func() error
+		want  string
+	}{
+		{
+			name: "replace multi-line array",
+			apply: func() error {
+				return SetSetting(mustKey(t, MirrorSetting), []string{"https://a.example/", "https://b,#.example/"})
+			},
+			want: `# vg configuration
+mirror = ["https://a.example/", "https://b,#.example/"]
+
+# Retry settings
+[download]
+retries = 5 # plenty
+`,
+		},
+		{
+			name:  "add top-level key before the first table",
+			apply: func() error { return SetSetting(mustKey(t, AutoInstallSetting), []string{"never"}) },
+			want: `# vg configuration
+mirror = ["https://a.example/", "https://b,#.example/"]
+auto_install = "never"
+
+# Retry settings
+[download]
+retries = 5 # plenty
+`,
+		},
+		{
+			name:  "add key to an existing table",
+			apply: func() error { return SetSetting(mustKey(t, ChecksumSetting), []string{"warn"}) },
+			want: `# vg configuration
+mirror = ["https://a.example/", "https://b,#.example/"]
+auto_install = "never"
+
+# Retry settings
+[download]
+retries = 5 # plenty
+checksum = "warn"
+`,
+		},
+		{
+			name:  "replace integer",
+			apply: func() error { return SetSetting(mustKey(t, RetriesSetting), []string{"2"}) },
+			want: `# vg configuration
+mirror = ["https://a.example/", "https://b,#.example/"]
+auto_install = "never"
+
+# Retry settings
+[download]
+retries = 2 # plenty
+checksum = "warn"
+`,
+		},
+		{
+			name: "unset",
+			apply: func() error {
+				_, err := UnsetSetting(mustKey(t, MirrorSetting))
+				return err
+			},
+			want: `# vg configuration
+auto_install = "never"
+
+# Retry settings
+[download]
+retries = 2 # plenty
+checksum = "warn"
+`,
+		},
+	}
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != step.want {
+			t.Fatalf("%s: file =\n%s\nwant\n%s", step.name, data, step.want)
+		}
+	}
+
+	// What was written reads back
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.AutoInstall != AutoInstallNever || c.Download.Checksum != ChecksumWarn || c.Download.Retries == nil || *c.Download.Retries != 2 || c.Mirrors != nil {
+		t.Errorf("Load() = %+v", c)
+	}
+
+	found, err := UnsetSetting(mustKey(t, MirrorSetting))
+	if err != nil || found {
+		t.Errorf("unsetting a missing key = %v, %v; want false, nil", found, err)
+	}
+}
+
+func TestSetSettingCreatesFile(t *testing.T) {
+	path := setup(t)
+	if err := SetSetting(mustKey(t, SourceSetting), []string{SourceProxy}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[download]\nsource = \"proxy\"\n"; string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
+
+func TestLookupOrigin(t *testing.T) {
+	path := setup(t)
+	if err := os.WriteFile(path, []byte("mirror = \"https://file.example/\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := mustKey(t, MirrorSetting)
+	if v := settings.Lookup(k); v.Origin != OriginFile || v.String() != "https://file.example/" {
+		t.Errorf("file: Lookup = %v from %s", v, v.Origin)
+	}
+	t.Setenv(MirrorEnvVar, "https://a.example/, https://b.example/")
+	if v := settings.Lookup(k); v.Origin != OriginEnv || !reflect.DeepEqual(v.Values, []string{"https://a.example/", "https://b.example/"}) {
+		t.Errorf("env: Lookup = %q from %s", v.Values, v.Origin)
+	}
+	if v := (Settings{}).Lookup(mustKey(t, RetriesSetting)); v.Origin != OriginDefault || v.String() != "3" {
+		t.Errorf("default: Lookup = %v from %s", v, v.Origin)
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	path := setup(t)
+	if err := os.WriteFile(path, []byte("[download]\nchecksum = \"maybe\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Load() = %v, want an error naming %s", err, path)
+	}
+}
+
+func mustKey(t *testing.T, name string) *Key {
+	t.Helper()
+	k, err := LookupKey(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a setting's value.
+type Kind int
+
+const (
+	// KindString is a string, written quoted.
+	KindString Kind = iota
+	// KindList is a list of strings. Environment variables hold it
+	// comma-separated.
+	KindList
+	// KindInt is a non-negative integer, written unquoted.
+	KindInt
+	// KindDuration is a duration such as "2s", written quoted.
+	KindDuration
+)
+
+// Key describes a setting of the configuration file.
+type Key struct {
+	// Name is the key in the configuration file. Keys inside a [table] are
+	// prefixed with the table name and a dot.
+	Name string
+	// EnvVar overrides the setting if it is set and not empty.
+	EnvVar string
+	Kind   Kind
+	// Choices are the allowed values, if limited.
+	Choices []string
+	// Default is the value used if the setting is unset, for display.
+	Default string
+	Usage   string
+}
+
+// Table returns the [table] holding the key, "" for top-level keys, and the
+// key name inside it.
+func (k *Key) Table() (table, name string) {
+	if i := strings.LastIndex(k.Name, "."); i >= 0 {
+		return k.Name[:i], k.Name[i+1:]
+	}
+	return "", k.Name
+}
+
+// Validate checks a single value of the key.
+func (k *Key) Validate(value string) error {
+	switch {
+	case len(k.Choices) > 0:
+		if !slices.Contains(k.Choices, value) {
+			return fmt.Errorf("invalid %s %q (expected %s)", k.Name, value, strings.Join(k.Choices, ", "))
+		}
+	case k.Kind == KindInt:
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return fmt.Errorf("invalid %s %q (expected a non-negative integer)", k.Name, value)
+		}
+	case k.Kind == KindDuration:
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("invalid %s %q (expected a duration such as 2s)", k.Name, value)
+		}
+	}
+	return nil
+}
+
+// Setting names and the environment variables overriding them.
+const (
+	MirrorSetting = "mirror"
+	// MirrorEnvVar holds one base URL or a comma-separated list tried in
+	// order.
+	MirrorEnvVar = "VG_MIRROR"
+
+	AutoInstallSetting = "auto_install"
+	AutoInstallEnvVar  = "VG_AUTO_INSTALL"
+
+	DefaultVersionSetting = "default_version"
+	DefaultVersionEnvVar  = "VG_DEFAULT_VERSION"
+
+	GomodcacheSetting = "gomodcache"
+	GomodcacheEnvVar  = "VG_GOMODCACHE"
+
+	ChecksumSetting = "download.checksum"
+	ChecksumEnvVar  = "VG_DOWNLOAD_CHECKSUM"
+
+	RetriesSetting = "download.retries"
+	RetriesEnvVar  = "VG_DOWNLOAD_RETRIES"
+
+	RetryDelaySetting = "download.retry_delay"
+	// RetryDelayEnvVar holds the delay before the first retry, e.g. "2s".
+	RetryDelayEnvVar = "VG_DOWNLOAD_RETRY_DELAY"
+
+	SourceSetting = "download.source"
+	SourceEnvVar  = "VG_DOWNLOAD_SOURCE"
+
+	GosumSetting = "download.gosum"
+	// GosumEnvVar names a go.sum file pinning golang.org/toolchain hashes.
+	GosumEnvVar = "VG_TOOLCHAIN_GOSUM"
+)
+
+// DefaultMirror is the download mirror used when none is configured.
+const DefaultMirror = "https://go.dev/dl/"
+
+// AutoInstall controls whether 'vg use' installs missing versions.
+type AutoInstall string
+
+const (
+	// AutoInstallPrompt asks before installing a version named on the
+	// command line and installs versions pinned by the project.
+	AutoInstallPrompt AutoInstall = "prompt"
+	// AutoInstallAlways installs missing versions without asking.
+	AutoInstallAlways AutoInstall = "always"
+	// AutoInstallNever fails if the version is not installed.
+	AutoInstallNever AutoInstall = "never"
+)
+
+// GomodcacheMode selects where downloaded modules are stored.
+type GomodcacheMode string
+
+const (
+	// GomodcacheShared stores modules in one cache for all versions.
+	GomodcacheShared GomodcacheMode = "shared"
+	// GomodcachePerVersion stores modules in GOPATH/pkg/mod, the go
+	// command's default, so each version and virtual environment has its
+	// own.
+	GomodcachePerVersion GomodcacheMode = "per-version"
+)
+
+// ChecksumPolicy controls how downloaded archives are verified.
+type ChecksumPolicy string
+
+const (
+	// ChecksumStrict fails on archives not matching their checksum.
+	ChecksumStrict ChecksumPolicy = "strict"
+	// ChecksumWarn installs such archives after printing a warning.
+	ChecksumWarn ChecksumPolicy = "warn"
+	// ChecksumOff skips verification, e.g. for mirrors publishing no
+	// checksums.
+	ChecksumOff ChecksumPolicy = "off"
+)
+
+// Download sources.
+const (
+	// SourceDist downloads release archives from the mirrors.
+	SourceDist = "dist"
+	// SourceProxy downloads the golang.org/toolchain module from GOPROXY,
+	// like the go command does when switching toolchains.
+	SourceProxy = "proxy"
+)
+
+// Keys lists the settings of the configuration file.
+var Keys = []*Key{
+	{
+		Name:    MirrorSetting,
+		EnvVar:  MirrorEnvVar,
+		Kind:    KindList,
+		Default: DefaultMirror,
+		Usage:   "Download mirrors, tried in order",
+	},
+	{
+		Name:    AutoInstallSetting,
+		EnvVar:  AutoInstallEnvVar,
+		Choices: []string{string(AutoInstallPrompt), string(AutoInstallAlways), string(AutoInstallNever)},
+		Default: string(AutoInstallPrompt),
+		Usage:   "Whether 'vg use' installs missing versions",
+	},
+	{
+		Name:   DefaultVersionSetting,
+		EnvVar: DefaultVersionEnvVar,
+		Usage:  "Version used by 'vg use' when the project pins none",
+	},
+	{
+		Name:    GomodcacheSetting,
+		EnvVar:  GomodcacheEnvVar,
+		Choices: []string{string(GomodcacheShared), string(GomodcachePerVersion)},
+		Default: string(GomodcacheShared),
+		Usage:   "One GOMODCACHE for all versions, or one per GOPATH",
+	},
+	{
+		Name:    ChecksumSetting,
+		EnvVar:  ChecksumEnvVar,
+		Choices: []string{string(ChecksumStrict), string(ChecksumWarn), string(ChecksumOff)},
+		Default: string(ChecksumStrict),
+		Usage:   "What to do with archives not matching their checksum",
+	},
+	{
+		Name:    RetriesSetting,
+		EnvVar:  RetriesEnvVar,
+		Kind:    KindInt,
+		Default: "3",
+		Usage:   "Retries of a failed download",
+	},
+	{
+		Name:    RetryDelaySetting,
+		EnvVar:  RetryDelayEnvVar,
+		Kind:    KindDuration,
+		Default: "1s",
+		Usage:   "Delay before the first retry, doubled after each one",
+	},
+	{
+		Name:    SourceSetting,
+		EnvVar:  SourceEnvVar,
+		Choices: []string{SourceDist, SourceProxy},
+		Default: SourceDist,
+		Usage:   "Download release archives, or the toolchain module from GOPROXY",
+	},
+	{
+		Name:   GosumSetting,
+		EnvVar: GosumEnvVar,
+		Usage:  "go.sum file pinning golang.org/toolchain hashes",
+	},
+}
+
+// LookupKey returns the setting named name.
+func LookupKey(name string) (*Key, error) {
+	for _, k := range Keys {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown configuration key %q", name)
+}
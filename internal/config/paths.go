@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -16,7 +17,81 @@ const (
 	VgDirName         = ".vg"
 )
 
+const (
+	// HomeEnvVar overrides the vg home directory of the default layout.
+	HomeEnvVar = "VG_HOME"
+	// LayoutEnvVar selects the directory layout. "xdg" spreads the files of
+	// vg over the XDG base directories instead of keeping them in vg home.
+	LayoutEnvVar = "VG_LAYOUT"
+	// XDGLayout is the LayoutEnvVar value selecting the XDG layout.
+	XDGLayout = "xdg"
+	// XDGDirName is the directory of vg inside each XDG base directory.
+	XDGDirName = "vg"
+)
+
+// Layout holds the root directories vg keeps its files in. In the default
+// layout all of them are vg home; in the XDG layout each is the vg
+// directory of the matching XDG base directory.
+type Layout struct {
+	// Data holds the SDKs, downloaded archives, GOPATHs, GOENVs, virtual
+	// environments and the Go source clone.
+	Data string
+	// Cache holds the GOCACHEs and the shared GOMODCACHE.
+	Cache string
+	// State holds the current links, shims, locks, the activation journal
+	// and the checksum database state.
+	State string
+	// Config holds the configuration file.
+	Config string
+}
+
+// ResolveLayout returns the directory layout selected by $VG_LAYOUT. The
+// default layout uses $VG_HOME, or ~/.vg if it is unset. The XDG layout
+// uses $XDG_DATA_HOME, $XDG_CACHE_HOME, $XDG_STATE_HOME and
+// $XDG_CONFIG_HOME, falling back to the defaults of the XDG base directory
+// specification; $VG_HOME is ignored.
+func ResolveLayout() (*Layout, error) {
+	switch layout := os.Getenv(LayoutEnvVar); layout {
+	case "":
+		vgHome, err := GetVgHome()
+		if err != nil {
+			return nil, err
+		}
+		return &Layout{Data: vgHome, Cache: vgHome, State: vgHome, Config: vgHome}, nil
+	case XDGLayout:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		return &Layout{
+			Data:   xdgDir("XDG_DATA_HOME", home, ".local", "share"),
+			Cache:  xdgDir("XDG_CACHE_HOME", home, ".cache"),
+			State:  xdgDir("XDG_STATE_HOME", home, ".local", "state"),
+			Config: xdgDir("XDG_CONFIG_HOME", home, ".config"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid %s %q (expected %q or unset)", LayoutEnvVar, layout, XDGLayout)
+	}
+}
+
+// xdgDir returns the vg directory inside the XDG base directory named by
+// envVar, or inside the default below home. Relative paths are ignored, as
+// the specification requires.
+func xdgDir(envVar, home string, defaultDir ...string) string {
+	base := os.Getenv(envVar)
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(append([]string{home}, defaultDir...)...)
+	}
+	return filepath.Join(base, XDGDirName)
+}
+
+// GetVgHome returns the vg home of the default layout: $VG_HOME, or ~/.vg
+// if it is unset. Use ResolveLayout to locate files, since the XDG layout
+// does not use vg home.
 func GetVgHome() (string, error) {
+	if vgHome := os.Getenv(HomeEnvVar); vgHome != "" {
+		return filepath.Abs(vgHome)
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -25,44 +100,44 @@ func GetVgHome() (string, error) {
 }
 
 func GetDistsDir() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, DistsDirName), nil
+	return filepath.Join(layout.Data, DistsDirName), nil
 }
 
 func GetSdksDir() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, SdksDirName), nil
+	return filepath.Join(layout.Data, SdksDirName), nil
 }
 
 func GetGopathsDir() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, GopathsDirName), nil
+	return filepath.Join(layout.Data, GopathsDirName), nil
 }
 
 func GetGoenvsDir() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, GoenvsDirName), nil
+	return filepath.Join(layout.Data, GoenvsDirName), nil
 }
 
 // GetGomodcacheDir returns the shared GOMODCACHE directory for all versions
 func GetGomodcacheDir() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, GomodcacheDirName), nil
+	return filepath.Join(layout.Cache, GomodcacheDirName), nil
 }
 
 // GetVersionGoroot returns the GOROOT path for a specific version
@@ -94,11 +169,11 @@ func GetVersionGoenv(version string) (string, error) {
 
 // GetGocachesDir returns the directory containing version-specific GOCACHE directories
 func GetGocachesDir() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, GocachesDirName), nil
+	return filepath.Join(layout.Cache, GocachesDirName), nil
 }
 
 // GetVersionGocache returns the GOCACHE path for a specific version
@@ -112,38 +187,38 @@ func GetVersionGocache(version string) (string, error) {
 
 // GetCurrentLink returns the path to the 'current' symlink (GOROOT)
 func GetCurrentLink() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, "current"), nil
+	return filepath.Join(layout.State, "current"), nil
 }
 
 // GetCurrentGopathLink returns the path to the 'current-gopath' symlink
 func GetCurrentGopathLink() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, "current-gopath"), nil
+	return filepath.Join(layout.State, "current-gopath"), nil
 }
 
 // GetCurrentGocacheLink returns the path to the 'current-gocache' symlink
 func GetCurrentGocacheLink() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, "current-gocache"), nil
+	return filepath.Join(layout.State, "current-gocache"), nil
 }
 
 // GetCurrentGoenvLink returns the path to the 'current-goenv' symlink
 func GetCurrentGoenvLink() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, "current-goenv"), nil
+	return filepath.Join(layout.State, "current-goenv"), nil
 }
 
 const (
@@ -153,11 +228,11 @@ const (
 
 // GetEnvsDir returns the directory containing virtual environments
 func GetEnvsDir() (string, error) {
-	vgHome, err := GetVgHome()
+	layout, err := ResolveLayout()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(vgHome, EnvsDirName), nil
+	return filepath.Join(layout.Data, EnvsDirName), nil
 }
 
 // GetEnvDir returns the directory for a specific environment under a specific Go version
@@ -168,3 +243,59 @@ func GetEnvDir(version, name string) (string, error) {
 	}
 	return filepath.Join(envsDir, version, name), nil
 }
+
+const (
+	// ShimsDirName stores the shims generated by 'vg rehash'.
+	ShimsDirName = "shims"
+)
+
+// GetShimsDir returns the directory containing the go, gofmt and GOPATH/bin shims
+func GetShimsDir() (string, error) {
+	layout, err := ResolveLayout()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(layout.State, ShimsDirName), nil
+}
+
+const (
+	// LocksDirName stores the inter-process lock files.
+	LocksDirName = "locks"
+)
+
+// GetLocksDir returns the directory containing the lock files
+func GetLocksDir() (string, error) {
+	layout, err := ResolveLayout()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(layout.State, LocksDirName), nil
+}
+
+const (
+	// SumdbDirName stores the verified checksum database state and tiles.
+	SumdbDirName = "sumdb"
+)
+
+// GetSumdbDir returns the directory containing the checksum database cache
+func GetSumdbDir() (string, error) {
+	layout, err := ResolveLayout()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(layout.State, SumdbDirName), nil
+}
+
+const (
+	// SrcDirName stores the Go repository clone used for source builds.
+	SrcDirName = "src"
+)
+
+// GetSrcDir returns the directory containing the Go source repository clone
+func GetSrcDir() (string, error) {
+	layout, err := ResolveLayout()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(layout.Data, SrcDirName), nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestResolveLayout(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("os.UserHomeDir does not read $HOME")
+	}
+	home := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs := func(elem ...string) string {
+		return filepath.Join(append([]string{home}, elem...)...)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Layout
+	}{
+		{
+			name: "default",
+			want: Layout{Data: abs(".vg"), Cache: abs(".vg"), State: abs(".vg"), Config: abs(".vg")},
+		},
+		{
+			name: "VG_HOME",
+			env:  map[string]string{HomeEnvVar: abs("custom")},
+			want: Layout{Data: abs("custom"), Cache: abs("custom"), State: abs("custom"), Config: abs("custom")},
+		},
+		{
+			name: "relative VG_HOME",
+			env:  map[string]string{HomeEnvVar: "relative"},
+			want: Layout{
+				Data:   filepath.Join(wd, "relative"),
+				Cache:  filepath.Join(wd, "relative"),
+				State:  filepath.Join(wd, "relative"),
+				Config: filepath.Join(wd, "relative"),
+			},
+		},
+		{
+			name: "XDG defaults",
+			env:  map[string]string{LayoutEnvVar: XDGLayout, HomeEnvVar: abs("ignored")},
+			want: Layout{
+				Data:   abs(".local", "share", "vg"),
+				Cache:  abs(".cache", "vg"),
+				State:  abs(".local", "state", "vg"),
+				Config: abs(".config", "vg"),
+			},
+		},
+		{
+			name: "XDG variables",
+			env: map[string]string{
+				LayoutEnvVar:      XDGLayout,
+				"XDG_DATA_HOME":   abs("data"),
+				"XDG_CACHE_HOME":  abs("cache"),
+				"XDG_STATE_HOME":  abs("state"),
+				"XDG_CONFIG_HOME": abs("config"),
+			},
+			want: Layout{
+				Data:   abs("data", "vg"),
+				Cache:  abs("cache", "vg"),
+				State:  abs("state", "vg"),
+				Config: abs("config", "vg"),
+			},
+		},
+		{
+			name: "relative XDG variables are ignored",
+			env: map[string]string{
+				LayoutEnvVar:      XDGLayout,
+				"XDG_DATA_HOME":   "data",
+				"XDG_CACHE_HOME":  "./cache",
+				"XDG_STATE_HOME":  abs("state"),
+				"XDG_CONFIG_HOME": "config",
+			},
+			want: Layout{
+				Data:   abs(".local", "share", "vg"),
+				Cache:  abs(".cache", "vg"),
+				State:  abs("state", "vg"),
+				Config: abs(".config", "vg"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", home)
+			for _, name := range []string{HomeEnvVar, LayoutEnvVar, "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME", "XDG_CONFIG_HOME"} {
+				t.Setenv(name, tt.env[name])
+			}
+			got, err := ResolveLayout()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("ResolveLayout() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLayoutInvalid(t *testing.T) {
+	for _, layout := range []string{"XDG", "home", " xdg"} {
+		t.Setenv(LayoutEnvVar, layout)
+		if got, err := ResolveLayout(); err == nil {
+			t.Errorf("%s=%q: ResolveLayout() = %+v, want error", LayoutEnvVar, layout, *got)
+		}
+		// Every path derived from the layout fails too
+		if _, err := GetSdksDir(); err == nil {
+			t.Errorf("%s=%q: GetSdksDir() succeeded", LayoutEnvVar, layout)
+		}
+	}
+}
+
+func TestLayoutPaths(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(HomeEnvVar, "")
+	t.Setenv(LayoutEnvVar, XDGLayout)
+	for _, name := range []string{"XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME", "XDG_CONFIG_HOME"} {
+		t.Setenv(name, "")
+	}
+	layout, err := ResolveLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		get  func() (string, error)
+		want string
+	}{
+		{GetSdksDir, filepath.Join(layout.Data, SdksDirName)},
+		{GetGocachesDir, filepath.Join(layout.Cache, GocachesDirName)},
+		{GetCurrentLink, filepath.Join(layout.State, "current")},
+		{GetConfigFile, filepath.Join(layout.Config, ConfigFileName)},
+	} {
+		if got, err := tt.get(); err != nil || got != tt.want {
+			t.Errorf("got %s, %v; want %s", got, err, tt.want)
+		}
+	}
+}
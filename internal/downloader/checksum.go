@@ -0,0 +1,88 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+// ChecksumError is returned when an archive does not match its published checksum.
+type ChecksumError struct {
+	Path string
+	// Hash names the kind of checksum, "sha256" if empty
+	Hash     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	hash := e.Hash
+	if hash == "" {
+		hash = "sha256"
+	}
+	return fmt.Sprintf("checksum mismatch for %s: expected %s %s, got %s", e.Path, hash, e.Expected, e.Actual)
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 digest of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyChecksum compares the SHA-256 digest of the file at path with expected.
+func verifyChecksum(path, expected string) error {
+	actual, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return &ChecksumError{Path: path, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// verifyArchive checks the archive at path against its expected SHA-256
+// checksum as the checksum policy demands.
+func (in *Installer) verifyArchive(path, expected string) error {
+	c, err := in.config()
+	if err != nil {
+		return err
+	}
+	policy := c.Download.Checksum
+	name := strings.TrimSuffix(filepath.Base(path), PartSuffix)
+	if policy == config.ChecksumOff {
+		fmt.Fprintf(in.Log, "Skipping checksum verification of %s (%s = %s)\n", name, config.ChecksumSetting, policy)
+		return nil
+	}
+	fmt.Fprintf(in.Log, "Verifying sha256 checksum of %s...\n", name)
+	return in.applyPolicy(policy, verifyChecksum(path, expected))
+}
+
+// applyPolicy turns a checksum mismatch into a warning under
+// config.ChecksumWarn.
+func (in *Installer) applyPolicy(policy config.ChecksumPolicy, err error) error {
+	var checksumErr *ChecksumError
+	if policy == config.ChecksumWarn && errors.As(err, &checksumErr) {
+		fmt.Fprintf(in.Log, "⚠️  Warning: %v\n", err)
+		return nil
+	}
+	return err
+}
@@ -1,26 +1,67 @@
 package downloader
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 
+	"github.com/fun7257/vg/internal/config"
+
 	"github.com/schollz/progressbar/v3"
 )
 
-const BaseURL = "https://go.dev/dl/"
+// BaseURL is the default download mirror, used when none is configured.
+const BaseURL = config.DefaultMirror
+
+// Installer downloads and installs SDKs. Status messages are written to
+// Log and download progress is shown on the bars returned by NewBar, so that
+// several installations can run side by side with separate output.
+type Installer struct {
+	Log io.Writer
+	// Config is the configuration to install with, loaded on first use if
+	// nil.
+	Config *config.Config
+	// Platform is the platform to install SDKs for, the native one if zero.
+	// SDKs for other platforms are stored under their SDKName.
+	Platform Platform
+	// NewBar returns the progress bar for a download of total bytes, or -1
+	// if the size is unknown.
+	NewBar func(total int64) *progressbar.ProgressBar
+}
+
+// NewInstaller returns an Installer printing to stderr, which keeps stdout
+// free for command output.
+func NewInstaller() *Installer {
+	return &Installer{
+		Log: os.Stderr,
+		NewBar: func(total int64) *progressbar.ProgressBar {
+			return progressbar.DefaultBytes(total, "downloading")
+		},
+	}
+}
 
+// DownloadAndInstall installs version with an Installer printing to stderr.
 func DownloadAndInstall(version, distsDir, sdksDir string) error {
-	// 1. Construct URL
-	// e.g., go1.25.4.darwin-arm64.tar.gz
-	goos := runtime.GOOS
-	goarch := runtime.GOARCH
+	return NewInstaller().Install(version, distsDir, sdksDir)
+}
+
+// Install downloads, verifies and extracts version into sdksDir, caching the
+// archive in distsDir.
+func (in *Installer) Install(version, distsDir, sdksDir string) error {
+	c, err := in.config()
+	if err != nil {
+		return err
+	}
+	if c.Download.Source == config.SourceProxy {
+		return in.installFromProxy(version, distsDir, sdksDir)
+	}
+
+	// 1. Construct archive name
+	// e.g., go1.25.4.darwin-arm64.tar.gz or go1.25.4.windows-amd64.zip
+	platform := in.Platform.resolve()
 
 	// Handle version string: ensure it starts with "go" or just number
 	verStr := version
@@ -28,21 +69,36 @@ func DownloadAndInstall(version, distsDir, sdksDir string) error {
 		verStr = "go" + verStr
 	}
 
-	filename := fmt.Sprintf("%s.%s-%s.tar.gz", verStr, goos, goarch)
-	url := BaseURL + filename
+	filename := fmt.Sprintf("%s.%s-%s%s", verStr, platform.OS, platform.Arch, platform.archiveExt())
+
+	mirrors := mirrorsOf(c)
 
 	// 2. Check if already installed
 	// The SDK will be extracted to sdksDir/go<version> usually, or we rename it.
 	// Let's say we want sdksDir/<version>
 	// Note: The tarball contains a "go" directory at the root.
 
-	// We'll use the raw version number for the directory name, e.g., "1.25.4"
-	installPath := filepath.Join(sdksDir, strings.TrimPrefix(verStr, "go"))
-	if _, err := os.Stat(installPath); err == nil {
-		return fmt.Errorf("version %s is already installed at %s", version, installPath)
+	// We'll use the raw version number for the directory name, e.g., "1.25.4",
+	// with the platform appended for foreign SDKs
+	number := strings.TrimPrefix(verStr, "go")
+	name := SDKName(number, platform)
+	installPath, err := in.prepareInstall(sdksDir, name)
+	if err != nil {
+		return err
 	}
 
-	// 3. Download
+	// 3. Look up the published checksum
+	client := &IndexClient{Mirrors: mirrors, HTTPClient: http.DefaultClient, Log: in.Log}
+	releases, err := client.Releases(true)
+	if err != nil {
+		return err
+	}
+	file, err := FindFile(releases, filename)
+	if err != nil {
+		return fmt.Errorf("version %s not found: %w", version, err)
+	}
+
+	// 4. Download
 	if err := os.MkdirAll(distsDir, 0755); err != nil {
 		return fmt.Errorf("failed to create dists dir: %w", err)
 	}
@@ -52,162 +108,114 @@ func DownloadAndInstall(version, distsDir, sdksDir string) error {
 	// Track if we downloaded it fresh
 	downloaded := false
 
-	// Check if file exists and is valid? For now just check existence.
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		downloaded = true
-		fmt.Printf("Downloading %s...\n", url)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return err
-		}
-
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return err
-		}
-		defer func() {
-			_ = resp.Body.Close()
-		}()
-
-		switch resp.StatusCode {
-		case http.StatusOK:
-			// OK
-		case http.StatusNotFound:
-			return fmt.Errorf("version %s not found", version)
-		default:
-			return fmt.Errorf("failed to download: %s", resp.Status)
-		}
-
-		f, err := os.Create(filePath)
-		if err != nil {
+		fmt.Fprintf(in.Log, "Downloading %s...\n", filename)
+		if err := in.downloadFile(mirrors, file, filePath, version); err != nil {
 			return err
 		}
-		defer func() {
-			_ = f.Close()
-		}()
+	} else {
+		fmt.Fprintf(in.Log, "Archive found at %s, skipping download.\n", filePath)
 
-		bar := progressbar.DefaultBytes(
-			resp.ContentLength,
-			"downloading",
-		)
+		// 5. Verify the cached archive
+		if err := in.verifyArchive(filePath, file.SHA256); err != nil {
+			_ = os.Remove(filePath)
 
-		if _, err := io.Copy(io.MultiWriter(f, bar), resp.Body); err != nil {
-			return err
+			// A cached archive that fails verification has been damaged
+			// since it was stored. Fetch it again.
+			fmt.Fprintf(in.Log, "Cached archive failed verification (%v).\n", err)
+			fmt.Fprintf(in.Log, "Removed %s, downloading again...\n", filePath)
+			return in.Install(version, distsDir, sdksDir)
 		}
-	} else {
-		fmt.Printf("Archive found at %s, skipping download.\n", filePath)
 	}
 
-	// 4. Extract
-	fmt.Printf("\nExtracting to %s...\n", installPath)
-	if err := os.MkdirAll(installPath, 0755); err != nil {
-		return err
-	}
-
-	f, err := os.Open(filePath)
+	// 6. Extract into a staging directory, so an interrupted extraction
+	// never leaves a half-populated SDK under its final name
+	stagingPath, err := newStaging(sdksDir, name)
 	if err != nil {
 		return err
 	}
-	// We need to close f before we might remove it in error handling
-	fClosed := false
 	defer func() {
-		if !fClosed {
-			_ = f.Close()
-		}
+		_ = os.RemoveAll(stagingPath)
 	}()
 
-	if err := extractTarGz(f, installPath); err != nil {
-		// Close file handle first so we can remove it if needed
-		_ = f.Close()
-		fClosed = true
-
+	fmt.Fprintf(in.Log, "\nExtracting to %s...\n", stagingPath)
+	if err := extractArchive(filePath, stagingPath); err != nil {
 		if !downloaded {
 			// If we didn't download it just now, maybe the cache is corrupt.
 			// Especially "unexpected EOF" suggests truncation.
-			fmt.Printf("Extraction failed (%v). The cached archive might be corrupt.\n", err)
-			fmt.Printf("Removing %s and retrying...\n", filePath)
+			fmt.Fprintf(in.Log, "Extraction failed (%v). The cached archive might be corrupt.\n", err)
+			fmt.Fprintf(in.Log, "Removing %s and retrying...\n", filePath)
 
 			_ = os.Remove(filePath)
-			_ = os.RemoveAll(installPath)
+			_ = os.RemoveAll(stagingPath)
 
 			// Retry
-			return DownloadAndInstall(version, distsDir, sdksDir)
+			return in.Install(version, distsDir, sdksDir)
 		}
-
-		// Cleanup on failure
-		_ = os.RemoveAll(installPath)
 		return err
 	}
 
-	fmt.Println("Done!")
-	return nil
-}
-
-func extractTarGz(r io.Reader, destDir string) error {
-	gzr, err := gzip.NewReader(r)
-	if err != nil {
+	// 7. Sanity check, mark as complete and move into place
+	if err := commitInstall(stagingPath, installPath, name); err != nil {
 		return err
 	}
-	defer func() {
-		_ = gzr.Close()
-	}()
 
-	tr := tar.NewReader(gzr)
+	fmt.Fprintln(in.Log, "Done!")
+	return nil
+}
 
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
+// config returns the configuration of the installer, loading it once.
+func (in *Installer) config() (*config.Config, error) {
+	if in.Config == nil {
+		c, err := config.Load()
 		if err != nil {
-			return err
+			return nil, err
 		}
+		in.Config = c
+	}
+	return in.Config, nil
+}
 
-		// The tarball has "go/..." structure. We want to strip "go/".
-		name := header.Name
-		if strings.HasPrefix(name, "go/") {
-			name = strings.TrimPrefix(name, "go/")
-		} else {
-			// Skip files not in "go/" (unlikely for official builds)
-			continue
+// prepareInstall returns the install path of version, making sure it is not
+// installed yet and removing leftovers of earlier interrupted installs.
+// Callers must hold the SDK lock.
+func (in *Installer) prepareInstall(sdksDir, version string) (string, error) {
+	installPath := filepath.Join(sdksDir, version)
+	if IsInstalled(sdksDir, version) {
+		_ = MarkInstalled(sdksDir, version)
+		return "", fmt.Errorf("version %s is already installed at %s", version, installPath)
+	}
+	if _, err := os.Stat(installPath); err == nil {
+		// Left behind by an install that was interrupted before markers
+		// existed, or damaged since
+		fmt.Fprintf(in.Log, "Removing incomplete installation at %s...\n", installPath)
+		if err := os.RemoveAll(installPath); err != nil {
+			return "", err
 		}
+	}
+	removeStaleStaging(sdksDir, version)
+	return installPath, nil
+}
 
-		if name == "" {
-			continue
-		}
+// newStaging creates the directory an SDK is unpacked into before it is
+// moved into place, so an interrupted install never leaves a
+// half-populated SDK under its final name.
+func newStaging(sdksDir, version string) (string, error) {
+	if err := os.MkdirAll(sdksDir, 0755); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(sdksDir, stagingPrefix+version+"-")
+}
 
-		target := filepath.Join(destDir, name)
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(target, 0755); err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			// Ensure parent dir exists
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
-			}
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
-			if _, err := io.Copy(f, tr); err != nil {
-				_ = f.Close()
-				return err
-			}
-			if err := f.Close(); err != nil {
-				return err
-			}
-		case tar.TypeSymlink:
-			// Ensure parent dir exists
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
-			}
-			if err := os.Symlink(header.Linkname, target); err != nil {
-				return err
-			}
-		}
+// commitInstall checks the SDK unpacked in stagingPath, marks it as complete
+// and moves it to installPath.
+func commitInstall(stagingPath, installPath, version string) error {
+	if err := checkSDK(stagingPath, version); err != nil {
+		return err
 	}
-	return nil
+	if err := writeMarker(stagingPath, version); err != nil {
+		return err
+	}
+	return os.Rename(stagingPath, installPath)
 }
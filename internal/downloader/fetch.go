@@ -0,0 +1,200 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+const (
+	// PartSuffix is appended to archives that are still being downloaded.
+	PartSuffix = ".part"
+)
+
+// RetryPolicy controls how often an interrupted download is retried. The
+// delay doubles after each failed attempt, up to MaxDelay.
+type RetryPolicy struct {
+	Attempts     int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultRetryPolicy is used when neither the environment nor the
+// configuration file sets the retry values.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:     4,
+	InitialDelay: time.Second,
+	MaxDelay:     30 * time.Second,
+}
+
+// RetryPolicyOf returns the retry policy of configuration c, which takes
+// $VG_DOWNLOAD_RETRIES and $VG_DOWNLOAD_RETRY_DELAY, then the configuration
+// file into account, falling back to DefaultRetryPolicy.
+func RetryPolicyOf(c *config.Config) RetryPolicy {
+	policy := DefaultRetryPolicy
+	if c.Download.Retries != nil {
+		// retries counts the attempts after the first one
+		policy.Attempts = *c.Download.Retries + 1
+	}
+	if d := c.Download.RetryDelay; d != nil {
+		policy.InitialDelay = *d
+		if policy.MaxDelay < *d {
+			policy.MaxDelay = *d
+		}
+	}
+	return policy
+}
+
+// delay returns how long to wait before the given retry (1 for the first).
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.InitialDelay
+	for i := 1; i < retry && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDelay)
+}
+
+// errNotFound is returned when every mirror answered 404. It is not retried.
+var errNotFound = errors.New("not found on any mirror")
+
+// downloadFile fetches filename from the mirrors into filePath. Data is
+// written to filePath+".part" first; an existing partial file is resumed
+// with a Range request when the server supports it. Transient failures are
+// retried according to the retry policy. The file is moved into place only
+// once it is complete and matches file's checksum.
+func (in *Installer) downloadFile(mirrors []string, file *File, filePath, version string) error {
+	partPath := filePath + PartSuffix
+	if err := in.fetchWithRetry(http.DefaultClient, mirrors, file, partPath, version); err != nil {
+		return err
+	}
+
+	if err := in.verifyArchive(partPath, file.SHA256); err != nil {
+		// The partial data cannot be trusted; start over next time
+		_ = os.Remove(partPath)
+		return err
+	}
+	return os.Rename(partPath, filePath)
+}
+
+// fetchWithRetry completes partPath from the mirrors, retrying transient
+// failures according to the retry policy.
+func (in *Installer) fetchWithRetry(client *http.Client, mirrors []string, file *File, partPath, version string) error {
+	c, err := in.config()
+	if err != nil {
+		return err
+	}
+	policy := RetryPolicyOf(c)
+
+	for attempt := 1; ; attempt++ {
+		err = in.fetchPart(client, mirrors, file, partPath)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, errNotFound) {
+			return fmt.Errorf("version %s not found", version)
+		}
+		if attempt >= policy.Attempts {
+			return &NetworkError{Err: fmt.Errorf("failed to download after %d attempts: %w", attempt, err)}
+		}
+		wait := policy.delay(attempt)
+		fmt.Fprintf(in.Log, "⚠️  Download interrupted (%v), retrying in %s...\n", err, wait)
+		time.Sleep(wait)
+	}
+}
+
+// fetchPart makes one attempt at completing partPath, resuming from its
+// current size.
+func (in *Installer) fetchPart(client *http.Client, mirrors []string, file *File, partPath string) error {
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+	if file.Size > 0 && offset >= file.Size {
+		// Already complete, left over from an attempt that failed to rename
+		return nil
+	}
+
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, mirror, notFound, err := getFromMirrors(client, mirrors, file.Filename, header, in.Log)
+	if err != nil {
+		if notFound {
+			return fmt.Errorf("%w: %v", errNotFound, err)
+		}
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	fmt.Fprintf(in.Log, "Using mirror %s\n", mirror)
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, ok := rangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			// Appending would corrupt the file; start over on the next attempt
+			_ = os.Remove(partPath)
+			return fmt.Errorf("server resumed at %q instead of byte %d", resp.Header.Get("Content-Range"), offset)
+		}
+		fmt.Fprintf(in.Log, "Resuming download at %d bytes\n", offset)
+		flags |= os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is at least as long as the archive; the checksum
+		// decides whether it is usable
+		return nil
+	default:
+		// The server ignored the range, start from the beginning
+		offset = 0
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	bar := in.NewBar(total)
+	_ = bar.Set64(offset)
+
+	if _, err := io.Copy(io.MultiWriter(f, bar), resp.Body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// A connection closed early without an error still leaves a short file
+	if info, err := os.Stat(partPath); err == nil && total >= 0 && info.Size() < total {
+		return fmt.Errorf("connection closed after %d of %d bytes", info.Size(), total)
+	}
+	return nil
+}
+
+// rangeStart returns the first byte position of a Content-Range header such
+// as "bytes 100-199/200".
+func rangeStart(contentRange string) (int64, bool) {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	first, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	return start, err == nil && start >= 0
+}
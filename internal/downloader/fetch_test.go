@@ -0,0 +1,276 @@
+package downloader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fun7257/vg/internal/config"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// archiveServer serves one file and misbehaves on request: it cuts the
+// connection after a number of bytes, ignores Range headers or answers a
+// range with the wrong start.
+type archiveServer struct {
+	data []byte
+	// cutAfter is how many body bytes each request gets before the
+	// connection is closed; requests beyond its length get everything.
+	cutAfter []int
+	// ignoreRange answers every request with the whole file.
+	ignoreRange bool
+	// wrongStart answers ranges with a 206 of the whole file.
+	wrongStart bool
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (s *archiveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	n := len(s.ranges)
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.mu.Unlock()
+
+	body := s.data
+	status := http.StatusOK
+	if spec, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok && !s.ignoreRange {
+		start, _ := strconv.Atoi(strings.TrimSuffix(spec, "-"))
+		if start >= len(s.data) {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(s.data)))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if s.wrongStart {
+			start = 0
+		}
+		body = s.data[start:]
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(s.data)-1, len(s.data)))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+
+	if n < len(s.cutAfter) && s.cutAfter[n] < len(body) {
+		_, _ = w.Write(body[:s.cutAfter[n]])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	_, _ = w.Write(body)
+}
+
+func (s *archiveServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.ranges...)
+}
+
+// fetchSetup isolates the configuration and makes retries immediate.
+func fetchSetup(t *testing.T, retries int) {
+	t.Helper()
+	t.Setenv(config.HomeEnvVar, t.TempDir())
+	t.Setenv(config.LayoutEnvVar, "")
+	t.Setenv(config.RetriesEnvVar, strconv.Itoa(retries))
+	t.Setenv(config.RetryDelayEnvVar, "1ms")
+}
+
+func testInstaller() *Installer {
+	return &Installer{
+		Log: io.Discard,
+		NewBar: func(total int64) *progressbar.ProgressBar {
+			return progressbar.DefaultBytesSilent(total)
+		},
+	}
+}
+
+func testData() []byte {
+	return bytes.Repeat([]byte("0123456789abcdef"), 4096)
+}
+
+// fetch runs fetchWithRetry against s with an optional partial file.
+func fetch(t *testing.T, s *archiveServer, partial []byte) (string, error) {
+	t.Helper()
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	partPath := filepath.Join(t.TempDir(), "go.tar.gz"+PartSuffix)
+	if partial != nil {
+		if err := os.WriteFile(partPath, partial, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := &File{Filename: "go.tar.gz"}
+	err := testInstaller().fetchWithRetry(server.Client(), []string{server.URL + "/"}, file, partPath, "1.24.0")
+	return partPath, err
+}
+
+func checkFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("file has %d bytes, want %d matching bytes", len(got), len(want))
+	}
+}
+
+func checkRequests(t *testing.T, s *archiveServer, want ...string) {
+	t.Helper()
+	got := s.requests()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Range headers = %q, want %q", got, want)
+	}
+}
+
+func TestFetchResumesAfterCut(t *testing.T) {
+	fetchSetup(t, 3)
+	data := testData()
+	s := &archiveServer{data: data, cutAfter: []int{1000, 5000}}
+	partPath, err := fetch(t, s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, partPath, data)
+	checkRequests(t, s, "", "bytes=1000-", "bytes=6000-")
+}
+
+func TestFetchResumesPartialFile(t *testing.T) {
+	fetchSetup(t, 0)
+	data := testData()
+	s := &archiveServer{data: data}
+	partPath, err := fetch(t, s, data[:3000])
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, partPath, data)
+	checkRequests(t, s, "bytes=3000-")
+}
+
+func TestFetchRestartsWithoutRangeSupport(t *testing.T) {
+	fetchSetup(t, 1)
+	data := testData()
+	s := &archiveServer{data: data, cutAfter: []int{1000}, ignoreRange: true}
+	partPath, err := fetch(t, s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, partPath, data)
+	checkRequests(t, s, "", "bytes=1000-")
+}
+
+func TestFetchRejectsWrongContentRange(t *testing.T) {
+	fetchSetup(t, 1)
+	data := testData()
+	s := &archiveServer{data: data, wrongStart: true}
+	partPath, err := fetch(t, s, data[:3000])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The partial file is discarded instead of getting the whole file
+	// appended, and the retry downloads from scratch
+	checkFile(t, partPath, data)
+	checkRequests(t, s, "bytes=3000-", "")
+}
+
+func TestFetchRangeNotSatisfiable(t *testing.T) {
+	fetchSetup(t, 0)
+	data := testData()
+	s := &archiveServer{data: data}
+	partPath, err := fetch(t, s, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The complete file is left for the checksum to judge
+	checkFile(t, partPath, data)
+	checkRequests(t, s, fmt.Sprintf("bytes=%d-", len(data)))
+}
+
+func TestFetchGivesUp(t *testing.T) {
+	fetchSetup(t, 2)
+	data := testData()
+	s := &archiveServer{data: data, cutAfter: []int{100, 100, 100, 100}}
+	partPath, err := fetch(t, s, nil)
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Fatalf("err = %v, want NetworkError", err)
+	}
+	checkRequests(t, s, "", "bytes=100-", "bytes=200-")
+	// The partial data is kept for the next run
+	checkFile(t, partPath, data[:300])
+}
+
+func TestFetchNotFound(t *testing.T) {
+	fetchSetup(t, 3)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	partPath := filepath.Join(t.TempDir(), "go.tar.gz"+PartSuffix)
+	err := testInstaller().fetchWithRetry(server.Client(), []string{server.URL + "/"}, &File{Filename: "go.tar.gz"}, partPath, "1.99.0")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("err = %v, want not found", err)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests, want 1 (404 is not retried)", requests)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{Attempts: 10, InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+	for retry, want := range []time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 9: 5 * time.Second} {
+		if want == 0 {
+			continue
+		}
+		if got := policy.delay(retry); got != want {
+			t.Errorf("delay(%d) = %s, want %s", retry, got, want)
+		}
+	}
+}
+
+func TestRetryPolicyOf(t *testing.T) {
+	fetchSetup(t, 2)
+	t.Setenv(config.RetryDelayEnvVar, "45s")
+	c, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := RetryPolicyOf(c)
+	want := RetryPolicy{Attempts: 3, InitialDelay: 45 * time.Second, MaxDelay: 45 * time.Second}
+	if policy != want {
+		t.Errorf("policy = %+v, want %+v", policy, want)
+	}
+}
+
+func TestRangeStart(t *testing.T) {
+	for header, want := range map[string]int64{
+		"bytes 100-199/200": 100,
+		"bytes 0-9/*":       0,
+		"bytes */200":       -1,
+		"items 1-2/3":       -1,
+		"":                  -1,
+		"bytes -5-9/10":     -1,
+	} {
+		got, ok := rangeStart(header)
+		if !ok {
+			got = -1
+		}
+		if got != want {
+			t.Errorf("rangeStart(%q) = %d, want %d", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+// indexPath is the release index relative to a mirror base URL. It lists
+// published releases together with the SHA-256 checksum of each archive. By
+// default only the currently supported releases are listed; add include=all
+// to get every release.
+const indexPath = "?mode=json"
+
+// Release is a single Go release as described by the release index.
+type Release struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+	Files   []File `json:"files"`
+}
+
+// File is a downloadable artifact belonging to a release.
+type File struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"`
+}
+
+// IndexClient fetches the release index.
+type IndexClient struct {
+	// Mirrors are the base URLs tried in order.
+	Mirrors    []string
+	HTTPClient *http.Client
+	// Log receives mirror fallback warnings.
+	Log io.Writer
+}
+
+// NewIndexClient returns a client for the release index of the configured
+// mirrors, writing warnings to stderr.
+func NewIndexClient() (*IndexClient, error) {
+	c, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return newIndexClient(c), nil
+}
+
+// newIndexClient returns a client for the mirrors of configuration c.
+func newIndexClient(c *config.Config) *IndexClient {
+	return &IndexClient{
+		Mirrors:    mirrorsOf(c),
+		HTTPClient: http.DefaultClient,
+		Log:        os.Stderr,
+	}
+}
+
+// FetchIndex downloads and decodes the complete release index.
+func FetchIndex() ([]Release, error) {
+	client, err := NewIndexClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Releases(true)
+}
+
+// Releases downloads and decodes the release index, newest first. When all
+// is false only the currently supported releases are returned.
+func (c *IndexClient) Releases(all bool) ([]Release, error) {
+	path := indexPath
+	if all {
+		path += "&include=all"
+	}
+
+	resp, _, _, err := getFromMirrors(c.HTTPClient, c.Mirrors, path, nil, c.Log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release index: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var releases []Release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to decode release index: %w", err)
+	}
+	return releases, nil
+}
+
+// FindFile returns the index entry for the given archive filename.
+func FindFile(releases []Release, filename string) (*File, error) {
+	for _, r := range releases {
+		for i := range r.Files {
+			if r.Files[i].Filename == filename {
+				return &r.Files[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s is not listed in the release index", filename)
+}
+
+// Archive returns the binary archive of the release for the given platform,
+// or nil if the release does not ship one.
+func (r Release) Archive(goos, goarch string) *File {
+	for i := range r.Files {
+		f := &r.Files[i]
+		if f.Kind == "archive" && f.OS == goos && f.Arch == goarch {
+			return f
+		}
+	}
+	return nil
+}
+
+// Number returns the release version without the "go" prefix, e.g. "1.25.4".
+func (r Release) Number() string {
+	return strings.TrimPrefix(r.Version, "go")
+}
+
+// MatchPrefix reports whether version belongs to the release line given by
+// prefix, e.g. "1.22" matches "1.22", "1.22.3" and "1.22rc1" but not "1.2".
+func MatchPrefix(version, prefix string) bool {
+	version = strings.TrimPrefix(version, "go")
+	prefix = strings.TrimPrefix(prefix, "go")
+	if prefix == "" || version == prefix {
+		return true
+	}
+	if !strings.HasPrefix(version, prefix) {
+		return false
+	}
+	rest := version[len(prefix):]
+	return strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "rc") || strings.HasPrefix(rest, "beta")
+}
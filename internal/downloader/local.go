@@ -0,0 +1,299 @@
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fun7257/vg/internal/extract"
+)
+
+// ChecksumSuffix is appended to an archive name to find the file holding
+// its SHA-256 checksum, as published next to each archive on go.dev.
+const ChecksumSuffix = ".sha256"
+
+// LocalVersion returns the Go version of a local SDK archive (.tar.gz or
+// .zip) or GOROOT directory, read from its VERSION file.
+func LocalVersion(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	var data []byte
+	if info.IsDir() {
+		data, err = os.ReadFile(filepath.Join(path, "VERSION"))
+	} else {
+		data, err = readArchiveFile(path, "go/VERSION")
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read the Go version of %s: %w", path, err)
+	}
+
+	// The first line holds the version, e.g. "go1.24.0"; later lines hold
+	// metadata such as the build time
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	version := strings.TrimSpace(string(line))
+	if !strings.HasPrefix(version, "go") || strings.ContainsAny(version, " \t") {
+		return "", fmt.Errorf("%s is not a Go release (VERSION is %q)", path, version)
+	}
+	return strings.TrimPrefix(version, "go"), nil
+}
+
+// LocalPlatform returns the platform a local SDK archive or GOROOT
+// directory is built for. It is taken from an archive name such as
+// go1.24.0.linux-arm64.tar.gz, or else from the pkg/tool/<os>_<arch>
+// directory of the SDK; the native platform wins if there are several.
+func LocalPlatform(path string) (Platform, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Platform{}, err
+	}
+
+	var platforms []Platform
+	if info.IsDir() {
+		entries, _ := os.ReadDir(filepath.Join(path, "pkg", "tool"))
+		for _, entry := range entries {
+			if p, ok := toolDirPlatform(entry.Name()); ok && entry.IsDir() {
+				platforms = append(platforms, p)
+			}
+		}
+	} else {
+		if p, ok := archiveNamePlatform(filepath.Base(path)); ok {
+			return p, nil
+		}
+		err = walkArchive(path, func(name string) bool {
+			rest, ok := strings.CutPrefix(name, "go/pkg/tool/")
+			if !ok {
+				return true
+			}
+			dir, _, _ := strings.Cut(rest, "/")
+			if p, ok := toolDirPlatform(dir); ok && !slices.Contains(platforms, p) {
+				platforms = append(platforms, p)
+			}
+			// Tools of the native platform settle it
+			return !slices.Contains(platforms, NativePlatform())
+		})
+		if err != nil {
+			return Platform{}, fmt.Errorf("cannot read %s: %w", path, err)
+		}
+	}
+
+	switch {
+	case len(platforms) == 0:
+		return Platform{}, fmt.Errorf("cannot tell the platform of %s: it has no pkg/tool/<os>_<arch> directory", path)
+	case slices.Contains(platforms, NativePlatform()):
+		return NativePlatform(), nil
+	}
+	return platforms[0], nil
+}
+
+// archiveNamePlatform returns the platform in an archive name of the form
+// go<version>.<os>-<arch>.tar.gz or .zip.
+func archiveNamePlatform(name string) (Platform, bool) {
+	base := strings.TrimSuffix(strings.TrimSuffix(name, ".tar.gz"), ".zip")
+	goos, goarch, ok := strings.Cut(base[strings.LastIndex(base, ".")+1:], "-")
+	if !ok || !knownOS[goos] || goarch == "" {
+		return Platform{}, false
+	}
+	return Platform{OS: goos, Arch: goarch}, true
+}
+
+// toolDirPlatform returns the platform of a pkg/tool directory such as
+// linux_amd64.
+func toolDirPlatform(dir string) (Platform, bool) {
+	goos, goarch, ok := strings.Cut(dir, "_")
+	if !ok || !knownOS[goos] || goarch == "" {
+		return Platform{}, false
+	}
+	return Platform{OS: goos, Arch: goarch}, true
+}
+
+func walkArchive(archive string, fn func(name string) bool) error {
+	switch {
+	case strings.HasSuffix(archive, ".zip"):
+		return extract.WalkZip(archive, fn)
+	case !strings.HasSuffix(archive, ".tar.gz"):
+		return fmt.Errorf("unsupported archive format (expected .tar.gz or .zip)")
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return extract.WalkTarGz(f, fn)
+}
+
+func readArchiveFile(archive, name string) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(archive, ".zip"):
+		return extract.ReadZip(archive, name)
+	case !strings.HasSuffix(archive, ".tar.gz"):
+		return nil, fmt.Errorf("unsupported archive format (expected .tar.gz or .zip)")
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return extract.ReadTarGz(f, name)
+}
+
+// LocalChecksum returns the expected SHA-256 checksum of a local archive,
+// read from the archive path plus ".sha256" if present, otherwise looked up
+// by file name in the release index.
+func LocalChecksum(archive string) (string, error) {
+	data, err := os.ReadFile(archive + ChecksumSuffix)
+	if err == nil {
+		// Either the bare digest or "<digest>  <file name>"
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			return fields[0], nil
+		}
+		return "", fmt.Errorf("%s%s is empty", archive, ChecksumSuffix)
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	releases, err := FetchIndex()
+	if err != nil {
+		return "", fmt.Errorf("no %s file next to the archive and %w", ChecksumSuffix, err)
+	}
+	file, err := FindFile(releases, filepath.Base(archive))
+	if err != nil {
+		return "", err
+	}
+	return file.SHA256, nil
+}
+
+// InstallFrom installs the SDK named name (see SDKName) from a local archive
+// or GOROOT directory into sdksDir. An archive must match expectedSHA256 and is extracted like a
+// downloaded one; a directory is copied. Either way the SDK is assembled in
+// a staging directory and checked before it is moved into place.
+func (in *Installer) InstallFrom(path, name, expectedSHA256, sdksDir string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	installPath, err := in.prepareInstall(sdksDir, name)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		if err := in.verifyArchive(path, expectedSHA256); err != nil {
+			return err
+		}
+	}
+
+	stagingPath, err := newStaging(sdksDir, name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingPath)
+	}()
+
+	if info.IsDir() {
+		fmt.Fprintf(in.Log, "Copying %s to %s...\n", path, stagingPath)
+		err = copyTree(path, stagingPath)
+	} else {
+		fmt.Fprintf(in.Log, "Extracting to %s...\n", stagingPath)
+		err = extractArchive(path, stagingPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := commitInstall(stagingPath, installPath, name); err != nil {
+		return err
+	}
+	fmt.Fprintln(in.Log, "Done!")
+	return nil
+}
+
+// extractArchive extracts an SDK archive, .zip or .tar.gz, into destDir.
+func extractArchive(archive, destDir string) error {
+	if strings.HasSuffix(archive, ".zip") {
+		return extract.Zip(archive, destDir, "go/")
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return extract.TarGz(f, destDir, "go/")
+}
+
+// copyTree copies the directory src into the existing directory dest,
+// preserving file modes and symlinks.
+func copyTree(src, dest string) error {
+	// The walk does not descend into a symlinked root such as /usr/local/go
+	src, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, info.Mode().Perm()|0200)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info)
+		}
+		return fmt.Errorf("cannot copy %s: unsupported file type", path)
+	})
+}
+
+func copyFile(src, dest string, info fs.FileInfo) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,87 @@
+package downloader
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTarGz writes a .tar.gz archive holding empty files named names.
+func writeTarGz(t *testing.T, path string, names ...string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []interface{ Close() error }{tw, gw, f} {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLocalPlatformArchive(t *testing.T) {
+	native := NativePlatform()
+	nativeTools := "go/pkg/tool/" + native.OS + "_" + native.Arch + "/vet"
+	tests := []struct {
+		name    string
+		entries []string
+		want    Platform
+	}{
+		{"go1.24.0.linux-arm64.tar.gz", nil, Platform{OS: "linux", Arch: "arm64"}},
+		{"go1.24.0.darwin-amd64.tar.gz", []string{nativeTools}, Platform{OS: "darwin", Arch: "amd64"}},
+		{"go1.24.0.linux-armv6l.tar.gz", nil, Platform{OS: "linux", Arch: "armv6l"}},
+		{"custom.tar.gz", []string{"go/VERSION", "go/pkg/tool/windows_386/vet"}, Platform{OS: "windows", Arch: "386"}},
+		{"custom.tar.gz", []string{"go/pkg/tool/plan9_arm/vet", nativeTools}, native},
+		{"go1.24.0.src.tar.gz", []string{"go/VERSION", nativeTools}, native},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.name)
+		writeTarGz(t, path, tt.entries...)
+		got, err := LocalPlatform(path)
+		if err != nil || got != tt.want {
+			t.Errorf("LocalPlatform(%s with %q) = %v, %v; want %v", tt.name, tt.entries, got, err, tt.want)
+		}
+	}
+
+	// Nothing tells the platform
+	path := filepath.Join(t.TempDir(), "custom.tar.gz")
+	writeTarGz(t, path, "go/VERSION", "go/bin/go")
+	if got, err := LocalPlatform(path); err == nil {
+		t.Errorf("LocalPlatform without pkg/tool = %v, want error", got)
+	}
+}
+
+func TestLocalPlatformDir(t *testing.T) {
+	goroot := t.TempDir()
+	if _, err := LocalPlatform(goroot); err == nil {
+		t.Error("LocalPlatform of a directory without pkg/tool succeeded")
+	}
+
+	tools := filepath.Join(goroot, "pkg", "tool")
+	for _, dir := range []string{"linux_s390x", "not-a-platform"} {
+		if err := os.MkdirAll(filepath.Join(tools, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := LocalPlatform(goroot); err != nil || got != (Platform{OS: "linux", Arch: "s390x"}) {
+		t.Errorf("LocalPlatform = %v, %v; want linux/s390x", got, err)
+	}
+
+	native := NativePlatform()
+	if err := os.MkdirAll(filepath.Join(tools, native.OS+"_"+native.Arch), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LocalPlatform(goroot); err != nil || got != native {
+		t.Errorf("LocalPlatform with native tools = %v, %v; want %v", got, err, native)
+	}
+}
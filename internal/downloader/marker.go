@@ -0,0 +1,152 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// MarkerFileName is written into an SDK directory once it is completely
+	// installed. Directories without it are leftovers of interrupted installs.
+	MarkerFileName = ".vg-installed"
+	// stagingPrefix prefixes the temporary directories SDKs are extracted into.
+	stagingPrefix = ".staging-"
+)
+
+// Marker is the content of the install marker file.
+type Marker struct {
+	Version     string    `json:"version"`
+	InstalledAt time.Time `json:"installed_at"`
+	// SizeBytes is the size of the SDK on disk when it was installed, 0 in
+	// markers written before sizes were recorded.
+	SizeBytes int64 `json:"size_bytes,omitempty"`
+}
+
+// ReadMarker returns the install marker of the SDK at installPath.
+func ReadMarker(installPath string) (*Marker, error) {
+	data, err := os.ReadFile(filepath.Join(installPath, MarkerFileName))
+	if err != nil {
+		return nil, err
+	}
+	var m Marker
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid install marker in %s: %w", installPath, err)
+	}
+	return &m, nil
+}
+
+func writeMarker(installPath, version string) error {
+	size, err := dirSize(installPath)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(Marker{Version: version, InstalledAt: time.Now().UTC(), SizeBytes: size})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(installPath, MarkerFileName), append(data, '\n'), 0644)
+}
+
+// dirSize returns the total size of the files below dir. Symlinks are not
+// followed.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// IsInstalled reports whether version is completely installed in sdksDir.
+// SDKs installed before markers existed are checked by running their go
+// binary. IsInstalled never writes, so it needs no lock; MarkInstalled
+// records the result of the check.
+func IsInstalled(sdksDir, version string) bool {
+	installPath := filepath.Join(sdksDir, version)
+	if _, err := os.Stat(installPath); err != nil {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(installPath, MarkerFileName)); err == nil {
+		return true
+	}
+	return checkSDK(installPath, version) == nil
+}
+
+// MarkInstalled writes the missing install marker of an SDK installed
+// before markers existed, if it passes the checks of a fresh install.
+// Callers must hold the SDK lock.
+func MarkInstalled(sdksDir, version string) error {
+	installPath := filepath.Join(sdksDir, version)
+	if _, err := os.Stat(filepath.Join(installPath, MarkerFileName)); err == nil {
+		return nil
+	}
+	if err := checkSDK(installPath, version); err != nil {
+		return err
+	}
+	return writeMarker(installPath, version)
+}
+
+// IsStaging reports whether name is a staging directory inside sdksDir.
+func IsStaging(name string) bool {
+	return strings.HasPrefix(name, stagingPrefix)
+}
+
+// checkSDK verifies that the SDK at goroot has a go binary reporting version.
+// SDKs for other platforms cannot be run and are checked by their files.
+func checkSDK(goroot, version string) error {
+	if number, p := SplitSDKName(version); !p.IsNative() {
+		return checkForeignSDK(goroot, number, p)
+	}
+
+	goBin := filepath.Join(goroot, "bin", "go")
+	if _, err := os.Stat(goBin); err != nil {
+		return fmt.Errorf("incomplete SDK: %s is missing", goBin)
+	}
+
+	cmd := exec.Command(goBin, "version")
+	cmd.Env = append(os.Environ(), "GOROOT="+goroot, "GOTOOLCHAIN=local")
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("'%s version' failed: %w", goBin, err)
+	}
+
+	fields := strings.Fields(string(out))
+	if len(fields) < 3 || !reportsVersion(fields[2:], version) {
+		return fmt.Errorf("'%s version' reported %q, expected Go %s", goBin, strings.TrimSpace(string(out)), version)
+	}
+	return nil
+}
+
+// reportsVersion reports whether the output of 'go version' following "go
+// version" matches version. Source builds report "devel go1.N-<commit> ...".
+func reportsVersion(fields []string, version string) bool {
+	if commit, ok := strings.CutPrefix(version, SourcePrefix); ok {
+		return len(fields) >= 2 && fields[0] == "devel" && strings.HasSuffix(fields[1], "-"+commit)
+	}
+	return fields[0] == "go"+version
+}
+
+// removeStaleStaging removes staging directories left behind by interrupted
+// installs of version. Callers must hold the SDK lock.
+func removeStaleStaging(sdksDir, version string) {
+	matches, _ := filepath.Glob(filepath.Join(sdksDir, stagingPrefix+version+"-*"))
+	for _, m := range matches {
+		_ = os.RemoveAll(m)
+	}
+}
@@ -0,0 +1,77 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// legacySDK creates an SDK without install marker whose go binary reports
+// version, as installed by vg versions before markers existed.
+func legacySDK(t *testing.T, sdksDir, version string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go binary is a shell script")
+	}
+	goroot := filepath.Join(sdksDir, version)
+	if err := os.MkdirAll(filepath.Join(goroot, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho go version go" + version + " " + runtime.GOOS + "/" + runtime.GOARCH + "\n"
+	if err := os.WriteFile(filepath.Join(goroot, "bin", "go"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return goroot
+}
+
+func TestIsInstalledLegacySDK(t *testing.T) {
+	sdksDir := t.TempDir()
+	goroot := legacySDK(t, sdksDir, "1.20.14")
+
+	if !IsInstalled(sdksDir, "1.20.14") {
+		t.Fatal("legacy SDK not reported as installed")
+	}
+	if _, err := os.Stat(filepath.Join(goroot, MarkerFileName)); !os.IsNotExist(err) {
+		t.Fatal("IsInstalled wrote a marker")
+	}
+
+	if err := MarkInstalled(sdksDir, "1.20.14"); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadMarker(goroot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != "1.20.14" || m.InstalledAt.IsZero() || m.SizeBytes == 0 {
+		t.Errorf("marker = %+v", m)
+	}
+	if !IsInstalled(sdksDir, "1.20.14") {
+		t.Error("marked SDK not reported as installed")
+	}
+}
+
+func TestIsInstalledIncompleteSDK(t *testing.T) {
+	sdksDir := t.TempDir()
+	// A go binary reporting another version
+	legacySDK(t, sdksDir, "1.20.14")
+	if err := os.Rename(filepath.Join(sdksDir, "1.20.14"), filepath.Join(sdksDir, "1.21.0")); err != nil {
+		t.Fatal(err)
+	}
+	// No go binary at all
+	if err := os.MkdirAll(filepath.Join(sdksDir, "1.22.0", "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []string{"1.21.0", "1.22.0", "1.23.0"} {
+		if IsInstalled(sdksDir, version) {
+			t.Errorf("IsInstalled(%s) = true", version)
+		}
+		if err := MarkInstalled(sdksDir, version); err == nil {
+			t.Errorf("MarkInstalled(%s) succeeded", version)
+		}
+		if _, err := os.Stat(filepath.Join(sdksDir, version, MarkerFileName)); err == nil {
+			t.Errorf("%s: marker written", version)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package downloader
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+// mirrorsOf returns the ordered list of download base URLs of configuration
+// c: $VG_MIRROR, then the 'mirror' setting of the configuration file, then
+// go.dev.
+func mirrorsOf(c *config.Config) []string {
+	var normalized []string
+	for _, m := range c.Mirrors {
+		if m = strings.TrimSpace(m); m != "" {
+			normalized = append(normalized, normalizeMirror(m))
+		}
+	}
+	if len(normalized) == 0 {
+		normalized = []string{BaseURL}
+	}
+	return normalized
+}
+
+// normalizeMirror makes sure a base URL ends with a slash so that file names
+// can be appended to it.
+func normalizeMirror(m string) string {
+	if !strings.HasSuffix(m, "/") {
+		m += "/"
+	}
+	return m
+}
+
+// MirrorError describes why a mirror was skipped.
+type MirrorError struct {
+	Mirror string
+	Status int
+	Err    error
+}
+
+func (e *MirrorError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Mirror, e.Err)
+	}
+	return fmt.Sprintf("%s: %d %s", e.Mirror, e.Status, http.StatusText(e.Status))
+}
+
+func (e *MirrorError) Unwrap() error {
+	return e.Err
+}
+
+// NetworkError is returned when no mirror could be reached or a download
+// kept failing.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// getFromMirrors requests path from each mirror in turn and returns the
+// first successful response together with the mirror that served it.
+// Mirrors failing with a network error, 404, 410 or 5xx are skipped; other
+// statuses are returned as errors immediately. notFound reports whether
+// every mirror answered 404 or 410. header is added to every request and fallbacks
+// are reported to log.
+func getFromMirrors(client *http.Client, mirrors []string, path string, header http.Header, log io.Writer) (resp *http.Response, mirror string, notFound bool, err error) {
+	var errs []string
+	notFound = true
+	for i, m := range mirrors {
+		req, err := http.NewRequest("GET", m+path, nil)
+		if err != nil {
+			return nil, "", false, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		var mirrorErr *MirrorError
+		resp, err := client.Do(req)
+		switch {
+		case err != nil:
+			mirrorErr = &MirrorError{Mirror: m, Err: err}
+		case isNotFound(resp.StatusCode) || resp.StatusCode >= 500:
+			_ = resp.Body.Close()
+			mirrorErr = &MirrorError{Mirror: m, Status: resp.StatusCode}
+		case resp.StatusCode >= 400 && resp.StatusCode != http.StatusRequestedRangeNotSatisfiable:
+			_ = resp.Body.Close()
+			return nil, m, false, &MirrorError{Mirror: m, Status: resp.StatusCode}
+		default:
+			return resp, m, false, nil
+		}
+
+		if !isNotFound(mirrorErr.Status) {
+			notFound = false
+		}
+		errs = append(errs, mirrorErr.Error())
+		if i < len(mirrors)-1 {
+			fmt.Fprintf(log, "⚠️  Mirror failed (%v), trying next mirror...\n", mirrorErr)
+		}
+	}
+	return nil, "", notFound, &NetworkError{Err: fmt.Errorf("all mirrors failed: %s", strings.Join(errs, "; "))}
+}
+
+// isNotFound reports whether status means the file does not exist. Module
+// proxies answer 410 Gone for modules they refuse to serve.
+func isNotFound(status int) bool {
+	return status == http.StatusNotFound || status == http.StatusGone
+}
@@ -0,0 +1,136 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/fun7257/vg/internal/goversion"
+)
+
+// knownOS lists the GOOS values SDK names may carry as a platform suffix.
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "illumos": true, "ios": true, "linux": true,
+	"netbsd": true, "openbsd": true, "plan9": true, "solaris": true,
+	"windows": true,
+}
+
+// Platform is a GOOS/GOARCH pair an SDK is built for.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// NativePlatform returns the platform vg is running on.
+func NativePlatform() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// IsNative reports whether SDKs for p run on this machine. The zero
+// Platform is native.
+func (p Platform) IsNative() bool {
+	return p == Platform{} || p == NativePlatform()
+}
+
+// resolve returns p with the zero value replaced by the native platform.
+func (p Platform) resolve() Platform {
+	if p == (Platform{}) {
+		return NativePlatform()
+	}
+	return p
+}
+
+func (p Platform) String() string {
+	p = p.resolve()
+	return p.OS + "/" + p.Arch
+}
+
+// archiveExt returns the extension of the release archives for p.
+func (p Platform) archiveExt() string {
+	if p.resolve().OS == "windows" {
+		return ".zip"
+	}
+	return ".tar.gz"
+}
+
+// ValidatePlatform checks that p names a known operating system and an
+// architecture.
+func ValidatePlatform(p Platform) error {
+	if !knownOS[p.OS] || p.Arch == "" {
+		return fmt.Errorf("unsupported platform %s/%s", p.OS, p.Arch)
+	}
+	return nil
+}
+
+// SDKName returns the name of version for p in the SDK store: the version
+// itself for the native platform, otherwise the version followed by
+// ".<os>-<arch>" as in Go's toolchain names, e.g. "1.24.0.linux-arm64".
+func SDKName(version string, p Platform) string {
+	if p.IsNative() {
+		return version
+	}
+	return version + "." + p.OS + "-" + p.Arch
+}
+
+// SplitSDKName splits an SDK name into its version and platform.
+func SplitSDKName(name string) (string, Platform) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		if goos, goarch, ok := strings.Cut(name[i+1:], "-"); ok && knownOS[goos] && goarch != "" {
+			return name[:i], Platform{OS: goos, Arch: goarch}
+		}
+	}
+	return name, NativePlatform()
+}
+
+// SortSDKNames sorts SDK names by version, oldest first. The native SDK of
+// a version comes before those for other platforms, and source builds come
+// last.
+func SortSDKNames(names []string) {
+	slices.SortStableFunc(names, func(a, b string) int {
+		va, pa := SplitSDKName(a)
+		vb, pb := SplitSDKName(b)
+		if c := goversion.Compare(va, vb); c != 0 {
+			return c
+		}
+		if pa.IsNative() != pb.IsNative() {
+			if pa.IsNative() {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(pa.String(), pb.String())
+	})
+}
+
+// IsForeign reports whether the SDK name is built for another platform
+// and cannot run on this machine.
+func IsForeign(name string) bool {
+	_, p := SplitSDKName(name)
+	return !p.IsNative()
+}
+
+// checkForeignSDK verifies an SDK that cannot be run here by its files: the
+// go binary must exist and VERSION must name version.
+func checkForeignSDK(goroot, version string, p Platform) error {
+	goBin := filepath.Join(goroot, "bin", "go")
+	if p.OS == "windows" {
+		goBin += ".exe"
+	}
+	if _, err := os.Stat(goBin); err != nil {
+		return fmt.Errorf("incomplete SDK: %s is missing", goBin)
+	}
+
+	data, err := os.ReadFile(filepath.Join(goroot, "VERSION"))
+	if err != nil {
+		return fmt.Errorf("incomplete SDK: %w", err)
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	if strings.TrimSpace(first) != "go"+version {
+		return fmt.Errorf("%s/VERSION is %q, expected go%s", goroot, strings.TrimSpace(first), version)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package downloader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/extract"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+const (
+	// ProxyEnvVar lists the module proxies, as for the go command.
+	ProxyEnvVar = "GOPROXY"
+	// DefaultProxy is used when GOPROXY is unset.
+	DefaultProxy = "https://proxy.golang.org"
+
+	// ToolchainModule is the module the Go toolchains are published as.
+	ToolchainModule = "golang.org/toolchain"
+)
+
+// proxyClient is used for module proxies, which may be file:// URLs.
+var proxyClient = func() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	return &http.Client{Transport: t}
+}()
+
+// ToolchainVersion returns the golang.org/toolchain module version of a Go
+// release for a platform, e.g. "v0.0.1-go1.24.0.linux-amd64".
+func ToolchainVersion(version, goos, goarch string) string {
+	return fmt.Sprintf("v0.0.1-go%s.%s-%s", strings.TrimPrefix(version, "go"), goos, goarch)
+}
+
+// Proxies returns the module proxy base URLs from $GOPROXY. "direct" entries
+// are skipped, as toolchains are only published through proxies; "off"
+// stops the list.
+func Proxies() ([]string, error) {
+	value := os.Getenv(ProxyEnvVar)
+	if value == "" {
+		value = DefaultProxy
+	}
+	var proxies []string
+list:
+	for _, p := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '|' }) {
+		switch p = strings.TrimSpace(p); p {
+		case "", "direct":
+		case "off":
+			break list
+		default:
+			proxies = append(proxies, normalizeMirror(p))
+		}
+	}
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("%s=%s has no module proxy to download toolchains from", ProxyEnvVar, value)
+	}
+	return proxies, nil
+}
+
+// proxyVersions lists the releases the proxies publish for a platform.
+func proxyVersions(goos, goarch string) ([]string, error) {
+	proxies, err := Proxies()
+	if err != nil {
+		return nil, err
+	}
+	resp, _, _, err := getFromMirrors(proxyClient, proxies, ToolchainModule+"/@v/list", nil, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s versions: %w", ToolchainModule, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	prefix, suffix := "v0.0.1-go", "."+goos+"-"+goarch
+	var versions []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		v := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(v, prefix) && strings.HasSuffix(v, suffix) {
+			versions = append(versions, strings.TrimSuffix(strings.TrimPrefix(v, prefix), suffix))
+		}
+	}
+	return versions, scanner.Err()
+}
+
+// installFromProxy installs version from the golang.org/toolchain module
+// zip served by GOPROXY, verified against the pinned go.sum or GOSUMDB.
+func (in *Installer) installFromProxy(version, distsDir, sdksDir string) error {
+	number := strings.TrimPrefix(version, "go")
+	platform := in.Platform.resolve()
+	modVersion := ToolchainVersion(number, platform.OS, platform.Arch)
+	name := SDKName(number, platform)
+
+	proxies, err := Proxies()
+	if err != nil {
+		return err
+	}
+	installPath, err := in.prepareInstall(sdksDir, name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(distsDir, 0755); err != nil {
+		return err
+	}
+	filePath := filepath.Join(distsDir, "toolchain@"+modVersion+".zip")
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		fmt.Fprintf(in.Log, "Downloading %s@%s...\n", ToolchainModule, modVersion)
+		partPath := filePath + PartSuffix
+		file := &File{Filename: ToolchainModule + "/@v/" + modVersion + ".zip"}
+		if err := in.fetchWithRetry(proxyClient, proxies, file, partPath, version); err != nil {
+			return err
+		}
+		if err := in.verifyToolchain(partPath, proxies, modVersion); err != nil {
+			_ = os.Remove(partPath)
+			return err
+		}
+		if err := os.Rename(partPath, filePath); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(in.Log, "Archive found at %s, skipping download.\n", filePath)
+		err := in.verifyToolchain(filePath, proxies, modVersion)
+		var checksumErr *ChecksumError
+		if errors.As(err, &checksumErr) {
+			_ = os.Remove(filePath)
+			fmt.Fprintf(in.Log, "Cached archive failed verification (%v).\n", err)
+			fmt.Fprintf(in.Log, "Removed %s, downloading again...\n", filePath)
+			return in.installFromProxy(version, distsDir, sdksDir)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	stagingPath, err := newStaging(sdksDir, name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingPath)
+	}()
+
+	fmt.Fprintf(in.Log, "\nExtracting to %s...\n", stagingPath)
+	if err := extract.Zip(filePath, stagingPath, ToolchainModule+"@"+modVersion+"/"); err != nil {
+		return err
+	}
+	// Module zips do not record file modes
+	if err := markToolsExecutable(stagingPath); err != nil {
+		return err
+	}
+
+	if err := commitInstall(stagingPath, installPath, name); err != nil {
+		return err
+	}
+	fmt.Fprintln(in.Log, "Done!")
+	return nil
+}
+
+// verifyToolchain checks the h1: hash of a toolchain module zip against the
+// pinned go.sum if it lists the version, otherwise against GOSUMDB.
+func (in *Installer) verifyToolchain(zipPath string, proxies []string, modVersion string) error {
+	c, err := in.config()
+	if err != nil {
+		return err
+	}
+	policy := c.Download.Checksum
+	if policy == config.ChecksumOff {
+		fmt.Fprintf(in.Log, "Skipping verification of %s@%s (%s = %s)\n", ToolchainModule, modVersion, config.ChecksumSetting, policy)
+		return nil
+	}
+
+	expected, found, err := pinnedSum(c.Download.Gosum, modVersion)
+	if err != nil {
+		return err
+	}
+	if found {
+		fmt.Fprintf(in.Log, "Verifying %s@%s against the pinned go.sum...\n", ToolchainModule, modVersion)
+	} else {
+		sumdb, err := loadSumdbConfig()
+		if err != nil {
+			return err
+		}
+		if sumdb == nil {
+			return fmt.Errorf("cannot verify %s@%s: %s=off and no hash is pinned in %s", ToolchainModule, modVersion, SumdbEnvVar, config.GosumEnvVar)
+		}
+		fmt.Fprintf(in.Log, "Verifying %s@%s against %s...\n", ToolchainModule, modVersion, sumdb.Name)
+		if expected, err = lookupSum(proxyClient, sumdb, proxies, ToolchainModule, modVersion); err != nil {
+			return err
+		}
+	}
+
+	actual, err := dirhash.HashZip(zipPath, dirhash.Hash1)
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return in.applyPolicy(policy, &ChecksumError{Path: zipPath, Hash: "module", Expected: expected, Actual: actual})
+	}
+	return nil
+}
+
+// pinnedSum looks up the hash of the toolchain module version in the go.sum
+// at path, the 'download.gosum' setting. Nothing is found if path is "".
+func pinnedSum(path, modVersion string) (sum string, found bool, err error) {
+	if path == "" {
+		return "", false, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 3 && fields[0] == ToolchainModule && fields[1] == modVersion {
+			return fields[2], true, nil
+		}
+	}
+	return "", false, scanner.Err()
+}
+
+// markToolsExecutable makes the files in bin/ and pkg/tool/ executable, as
+// the go command does after unpacking a toolchain module.
+func markToolsExecutable(goroot string) error {
+	for _, dir := range []string{"bin", filepath.Join("pkg", "tool")} {
+		err := filepath.WalkDir(filepath.Join(goroot, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				return os.Chmod(path, 0755)
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
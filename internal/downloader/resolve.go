@@ -0,0 +1,167 @@
+package downloader
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/goversion"
+)
+
+// IsAlias reports whether version may be an alias rather than an exact
+// release, i.e. "latest", "stable", a release line such as "1.23", a
+// pre-release line such as "1.23rc" or "1.23beta", or "tip" for the newest
+// source build. A release line is only an alias when no release has that
+// exact name: before Go 1.21 the first release of a line was named "1.20".
+func IsAlias(version string) bool {
+	version = strings.TrimPrefix(version, "go")
+	switch version {
+	case "latest", "stable", Tip:
+		return true
+	}
+	line := strings.TrimSuffix(strings.TrimSuffix(version, "rc"), "beta")
+	parts := strings.Split(line, ".")
+	if len(parts) != 2 {
+		return false
+	}
+	for _, p := range parts {
+		if _, err := strconv.Atoi(p); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveVersion turns an alias into an exact version number. Aliases are
+// resolved against the release index, falling back to the SDKs installed
+// in sdksDir when the index cannot be reached. "tip" resolves to the most
+// recently installed source build. Exact versions are returned unchanged
+// without the "go" prefix.
+func ResolveVersion(version, sdksDir string) (string, error) {
+	return ResolveVersionFor(version, sdksDir, NativePlatform())
+}
+
+// ResolveVersionFor is like ResolveVersion for the releases of platform p.
+func ResolveVersionFor(version, sdksDir string, p Platform) (string, error) {
+	p = p.resolve()
+	version = strings.TrimPrefix(version, "go")
+	if !IsAlias(version) {
+		return version, nil
+	}
+	if version == Tip {
+		return latestSourceBuild(sdksDir)
+	}
+	if isReleaseLine(version) && IsInstalled(sdksDir, SDKName(version, p)) {
+		return version, nil
+	}
+
+	candidates, err := remoteVersions(p)
+	if err == nil {
+		if isReleaseLine(version) && slices.Contains(candidates, version) {
+			return version, nil
+		}
+		if resolved := pickAlias(version, candidates); resolved != "" {
+			return resolved, nil
+		}
+		return "", fmt.Errorf("no release matches %s", version)
+	}
+
+	// Offline: resolve against installed SDKs
+	var installed []string
+	for _, name := range installedVersions(sdksDir) {
+		if number, platform := SplitSDKName(name); platform == p {
+			installed = append(installed, number)
+		}
+	}
+	if resolved := pickAlias(version, installed); resolved != "" {
+		return resolved, nil
+	}
+	return "", fmt.Errorf("cannot resolve %s: %w (and no installed version matches)", version, err)
+}
+
+// ResolveInstalled turns an alias into the installed SDK it stands for:
+// "tip" is the most recently installed source build and other aliases the
+// newest installed native SDK they match. Installed SDKs named exactly
+// version, and versions no SDK matches, are returned unchanged without the
+// "go" prefix. The release index is never consulted.
+func ResolveInstalled(version, sdksDir string) string {
+	version = strings.TrimPrefix(version, "go")
+	if !IsAlias(version) || IsInstalled(sdksDir, version) {
+		return version
+	}
+	if version == Tip {
+		if resolved, err := latestSourceBuild(sdksDir); err == nil {
+			return resolved
+		}
+		return version
+	}
+	var installed []string
+	for _, name := range installedVersions(sdksDir) {
+		if number, platform := SplitSDKName(name); platform.IsNative() {
+			installed = append(installed, number)
+		}
+	}
+	if resolved := pickAlias(version, installed); resolved != "" {
+		return resolved
+	}
+	return version
+}
+
+// isReleaseLine reports whether version names a release line such as
+// "1.23", which is also the name of the first release of lines before 1.21.
+func isReleaseLine(version string) bool {
+	return IsAlias(version) && goversion.IsValid(version)
+}
+
+// remoteVersions lists the releases available for platform p from the
+// configured download source.
+func remoteVersions(p Platform) ([]string, error) {
+	c, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if c.Download.Source == config.SourceProxy {
+		return proxyVersions(p.OS, p.Arch)
+	}
+
+	releases, err := newIndexClient(c).Releases(true)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, r := range releases {
+		if r.Archive(p.OS, p.Arch) != nil {
+			versions = append(versions, r.Number())
+		}
+	}
+	return versions, nil
+}
+
+// pickAlias returns the newest of candidates matching alias, or "".
+func pickAlias(alias string, candidates []string) string {
+	best := ""
+	for _, c := range candidates {
+		if IsSourceBuild(c) || !matchAlias(alias, c) {
+			continue
+		}
+		if best == "" || goversion.Compare(c, best) > 0 {
+			best = c
+		}
+	}
+	return best
+}
+
+func matchAlias(alias, version string) bool {
+	switch {
+	case alias == "latest" || alias == "stable":
+		return goversion.IsStable(version)
+	case strings.HasSuffix(alias, "rc"):
+		return strings.HasPrefix(version, alias) && len(version) > len(alias)
+	case strings.HasSuffix(alias, "beta"):
+		return strings.HasPrefix(version, alias) && len(version) > len(alias)
+	default:
+		return goversion.IsStable(version) && MatchPrefix(version, alias)
+	}
+}
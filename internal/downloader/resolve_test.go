@@ -0,0 +1,158 @@
+package downloader
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+func TestIsAlias(t *testing.T) {
+	for version, want := range map[string]bool{
+		"latest":    true,
+		"stable":    true,
+		"tip":       true,
+		"1.23":      true,
+		"go1.23":    true,
+		"1.23rc":    true,
+		"1.23beta":  true,
+		"1.23.4":    false,
+		"1.23rc1":   false,
+		"1.21rc2":   false,
+		"1.23beta1": false,
+		"tip-abc":   false,
+		"1":         false,
+	} {
+		if got := IsAlias(version); got != want {
+			t.Errorf("IsAlias(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestPickAlias(t *testing.T) {
+	candidates := []string{"1.24rc1", "1.23.4", "1.23.10", "1.23rc2", "1.23rc1", "1.23beta1", "1.20", "1.20.14", "1.2.2", "tip-abc"}
+	for alias, want := range map[string]string{
+		"latest":   "1.23.10",
+		"stable":   "1.23.10",
+		"1.23":     "1.23.10",
+		"1.23rc":   "1.23rc2",
+		"1.24rc":   "1.24rc1",
+		"1.23beta": "1.23beta1",
+		"1.20":     "1.20.14",
+		"1.2":      "1.2.2",
+		"1.22":     "",
+		"1.24":     "",
+	} {
+		if got := pickAlias(alias, candidates); got != want {
+			t.Errorf("pickAlias(%q) = %q, want %q", alias, got, want)
+		}
+	}
+}
+
+// indexServer serves a release index listing versions for the native
+// platform and points the configuration at it.
+func indexServer(t *testing.T, versions ...string) {
+	t.Helper()
+	var releases []Release
+	for _, v := range versions {
+		releases = append(releases, Release{
+			Version: "go" + v,
+			Files:   []File{{Kind: "archive", OS: runtime.GOOS, Arch: runtime.GOARCH}},
+		})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if versions == nil {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(releases)
+	}))
+	t.Cleanup(server.Close)
+	fetchSetup(t, 1)
+	t.Setenv(config.MirrorEnvVar, server.URL+"/")
+}
+
+func TestResolveVersionPrefersExactRelease(t *testing.T) {
+	indexServer(t, "1.23.4", "1.23rc2", "1.20.14", "1.20", "1.19.13", "1.19")
+	sdksDir := t.TempDir()
+
+	for version, want := range map[string]string{
+		"1.20":    "1.20",
+		"go1.19":  "1.19",
+		"1.23":    "1.23.4",
+		"latest":  "1.23.4",
+		"1.23rc":  "1.23rc2",
+		"1.20.14": "1.20.14",
+	} {
+		got, err := ResolveVersion(version, sdksDir)
+		if err != nil || got != want {
+			t.Errorf("ResolveVersion(%q) = %q, %v; want %q", version, got, err, want)
+		}
+	}
+	if got, err := ResolveVersion("1.22", sdksDir); err == nil {
+		t.Errorf("ResolveVersion(1.22) = %q, want error", got)
+	}
+}
+
+func TestResolveVersionOffline(t *testing.T) {
+	indexServer(t)
+	sdksDir := t.TempDir()
+	legacySDK(t, sdksDir, "1.20")
+	legacySDK(t, sdksDir, "1.20.14")
+	legacySDK(t, sdksDir, "1.21.3")
+
+	for version, want := range map[string]string{
+		"1.20":   "1.20",
+		"1.21":   "1.21.3",
+		"latest": "1.21.3",
+	} {
+		got, err := ResolveVersion(version, sdksDir)
+		if err != nil || got != want {
+			t.Errorf("ResolveVersion(%q) = %q, %v; want %q", version, got, err, want)
+		}
+	}
+	if got, err := ResolveVersion("1.22", sdksDir); err == nil {
+		t.Errorf("ResolveVersion(1.22) = %q, want error", got)
+	}
+}
+
+func TestResolveInstalled(t *testing.T) {
+	sdksDir := t.TempDir()
+	legacySDK(t, sdksDir, "1.20")
+	legacySDK(t, sdksDir, "1.22.1")
+	legacySDK(t, sdksDir, "1.22.10")
+	for _, name := range []string{"tip-aaaaaaaaaa", "tip-bbbbbbbbbb"} {
+		goroot := filepath.Join(sdksDir, name)
+		if err := os.MkdirAll(goroot, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeMarker(goroot, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for version, want := range map[string]string{
+		"1.20":    "1.20",
+		"1.22":    "1.22.10",
+		"go1.22":  "1.22.10",
+		"latest":  "1.22.10",
+		"tip":     "tip-bbbbbbbbbb",
+		"1.22.1":  "1.22.1",
+		"1.23":    "1.23",
+		"go1.9.7": "1.9.7",
+	} {
+		if got := ResolveInstalled(version, sdksDir); got != want {
+			t.Errorf("ResolveInstalled(%q) = %q, want %q", version, got, want)
+		}
+	}
+
+	// Without a source build tip stays as it is
+	if got := ResolveInstalled("tip", t.TempDir()); got != "tip" {
+		t.Errorf("ResolveInstalled(tip) = %q without source builds", got)
+	}
+}
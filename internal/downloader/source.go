@@ -0,0 +1,300 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fun7257/vg/internal/extract"
+	"github.com/fun7257/vg/internal/goversion"
+)
+
+const (
+	// SourcePrefix names SDKs built from a commit of the Go repository. It is
+	// followed by the abbreviated commit hash, e.g. "tip-1a2b3c4d5e".
+	SourcePrefix = "tip-"
+	// Tip builds the main branch with 'vg install', and elsewhere stands for
+	// the most recently installed source build.
+	Tip = "tip"
+	// TipRef is the git ref built by 'vg install tip'.
+	TipRef = "master"
+
+	// GoRepoURL is the Go repository cloned for source builds.
+	GoRepoURL = "https://go.googlesource.com/go"
+	// GoRepoEnvVar overrides GoRepoURL.
+	GoRepoEnvVar = "VG_GO_REPO"
+
+	// commitAbbrev is the length of the commit hash in source build names.
+	commitAbbrev = 10
+)
+
+// IsSourceBuild reports whether version names an SDK built from a commit.
+func IsSourceBuild(version string) bool {
+	return strings.HasPrefix(version, SourcePrefix)
+}
+
+// Source is Go source code to build an SDK from: a commit of a git
+// repository, or a source tarball such as go1.24.0.src.tar.gz.
+type Source struct {
+	Repo    string
+	Commit  string
+	Tarball string
+	// Version is the name the SDK is installed under
+	Version string
+}
+
+// FindSource locates the source named by spec: a source tarball, the HEAD
+// commit of a local git checkout, or else a git ref (branch, tag, commit or
+// change ref) fetched into the clone of the Go repository kept in srcDir.
+func (in *Installer) FindSource(spec, srcDir string) (*Source, error) {
+	if info, err := os.Stat(spec); err == nil {
+		if info.IsDir() {
+			return gitSource(spec, "HEAD")
+		}
+		version, err := LocalVersion(spec)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{Tarball: spec, Version: version}, nil
+	}
+
+	repo := filepath.Join(srcDir, "go.git")
+	if _, err := os.Stat(repo); os.IsNotExist(err) {
+		url := os.Getenv(GoRepoEnvVar)
+		if url == "" {
+			url = GoRepoURL
+		}
+		fmt.Fprintf(in.Log, "Cloning %s into %s...\n", url, repo)
+		if err := os.MkdirAll(srcDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := in.git("", "clone", "--bare", url, repo); err != nil {
+			_ = os.RemoveAll(repo)
+			return nil, err
+		}
+	}
+
+	fmt.Fprintf(in.Log, "Fetching %s...\n", spec)
+	if err := in.git(repo, "fetch", "origin", spec); err != nil {
+		// Servers may refuse to fetch a commit by hash; it may be known
+		// from an earlier fetch
+		if src, verr := gitSource(repo, spec); verr == nil {
+			return src, nil
+		}
+		return nil, err
+	}
+	return gitSource(repo, "FETCH_HEAD")
+}
+
+func gitSource(repo, ref string) (*Source, error) {
+	out, err := gitOutput(repo, "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	commit := strings.TrimSpace(out)
+	if len(commit) < commitAbbrev {
+		return nil, fmt.Errorf("unexpected commit hash %q", commit)
+	}
+	return &Source{Repo: repo, Commit: commit, Version: SourcePrefix + commit[:commitAbbrev]}, nil
+}
+
+// BuildSource builds src with make.bash, using the SDK at bootstrap as
+// GOROOT_BOOTSTRAP, and installs the result into sdksDir under src.Version.
+// The build runs in a staging directory that is only moved into place once
+// the new toolchain works.
+func (in *Installer) BuildSource(src *Source, bootstrap, sdksDir string) error {
+	installPath, err := in.prepareInstall(sdksDir, src.Version)
+	if err != nil {
+		return err
+	}
+	stagingPath, err := newStaging(sdksDir, src.Version)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingPath)
+	}()
+
+	fmt.Fprintf(in.Log, "Unpacking source into %s...\n", stagingPath)
+	if src.Tarball != "" {
+		err = extractArchive(src.Tarball, stagingPath)
+	} else {
+		err = exportCommit(src, stagingPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(in.Log, "Building with GOROOT_BOOTSTRAP=%s...\n", bootstrap)
+	cmd := exec.Command("bash", "make.bash")
+	cmd.Dir = filepath.Join(stagingPath, "src")
+	cmd.Env = buildEnv(bootstrap)
+	cmd.Stdout = in.Log
+	cmd.Stderr = in.Log
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("make.bash failed: %w", err)
+	}
+
+	if err := commitInstall(stagingPath, installPath, src.Version); err != nil {
+		return err
+	}
+	fmt.Fprintln(in.Log, "Done!")
+	return nil
+}
+
+// exportCommit writes the tree of the source commit into dir, with a
+// VERSION file identifying the commit as the go command does for
+// development builds.
+func exportCommit(src *Source, dir string) error {
+	cmd := exec.Command("git", "-C", src.Repo, "archive", "--format=tar", "--prefix=go/", src.Commit)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := extract.Tar(out, dir, "go/"); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive failed: %w", err)
+	}
+
+	minor, err := goVersionMinor(dir)
+	if err != nil {
+		return err
+	}
+	date, err := gitOutput(src.Repo, "log", "-1", "--format=%cd", src.Commit)
+	if err != nil {
+		return err
+	}
+	// Release branches commit a VERSION file; the build is named by commit
+	version := fmt.Sprintf("devel go1.%s-%s %s\n", minor, src.Commit[:commitAbbrev], strings.TrimSpace(date))
+	return os.WriteFile(filepath.Join(dir, "VERSION"), []byte(version), 0644)
+}
+
+var goVersionRE = regexp.MustCompile(`(?m)^const Version = (\d+)`)
+
+// goVersionMinor returns the minor version under development in the source
+// tree at goroot.
+func goVersionMinor(goroot string) (string, error) {
+	path := filepath.Join(goroot, "src", "internal", "goversion", "goversion.go")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("not a Go source tree: %w", err)
+	}
+	m := goVersionRE.FindSubmatch(data)
+	if m == nil {
+		return "", fmt.Errorf("cannot find the Go version in %s", path)
+	}
+	return string(m[1]), nil
+}
+
+// buildEnv returns the environment for make.bash. Settings of the current
+// toolchain must not leak into the build.
+func buildEnv(bootstrap string) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		switch name {
+		case "GOROOT", "GOROOT_BOOTSTRAP", "GOROOT_FINAL", "GOTOOLCHAIN", "GOENV", "GOFLAGS":
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, "GOROOT_BOOTSTRAP="+bootstrap, "GOTOOLCHAIN=local", "GOENV=off")
+}
+
+// BootstrapGoroot returns the GOROOT of the installed SDK used to build from
+// source: version if given, otherwise the newest installed release.
+func BootstrapGoroot(sdksDir, version string) (string, error) {
+	if version != "" {
+		resolved, err := ResolveVersion(version, sdksDir)
+		if err != nil {
+			return "", err
+		}
+		if !IsInstalled(sdksDir, resolved) {
+			return "", fmt.Errorf("bootstrap version %s is not installed", resolved)
+		}
+		return filepath.Join(sdksDir, resolved), nil
+	}
+
+	best := ""
+	for _, v := range installedVersions(sdksDir) {
+		if !IsSourceBuild(v) && !IsForeign(v) && (best == "" || goversion.Compare(v, best) > 0) {
+			best = v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no installed Go version to bootstrap the build with (run 'vg install latest' first)")
+	}
+	return filepath.Join(sdksDir, best), nil
+}
+
+// installedVersions lists the completely installed SDKs in sdksDir.
+func installedVersions(sdksDir string) []string {
+	entries, _ := os.ReadDir(sdksDir)
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() && !IsStaging(entry.Name()) && IsInstalled(sdksDir, entry.Name()) {
+			versions = append(versions, entry.Name())
+		}
+	}
+	return versions
+}
+
+// latestSourceBuild returns the most recently installed source build.
+func latestSourceBuild(sdksDir string) (string, error) {
+	best := ""
+	var bestMarker *Marker
+	for _, v := range installedVersions(sdksDir) {
+		if !IsSourceBuild(v) {
+			continue
+		}
+		m, err := ReadMarker(filepath.Join(sdksDir, v))
+		if err != nil {
+			continue
+		}
+		if bestMarker == nil || m.InstalledAt.After(bestMarker.InstalledAt) {
+			best, bestMarker = v, m
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no source build installed (run 'vg install tip')")
+	}
+	return best, nil
+}
+
+// git runs a git command in repo, showing its output.
+func (in *Installer) git(repo string, args ...string) error {
+	if repo != "" {
+		args = append([]string{"-C", repo}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = in.Log
+	cmd.Stderr = in.Log
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// gitOutput runs a git command in repo and returns its output.
+func gitOutput(repo string, args ...string) (string, error) {
+	args = append([]string{"-C", repo}, args...)
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args[2:], " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args[2:], " "), err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,74 @@
+package downloader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/fun7257/vg/internal/config"
+
+	"golang.org/x/mod/sumdb"
+)
+
+// TestWriteConfigConcurrent increments a counter through compare and swap
+// from many writers, as concurrent installs update the latest tree; no
+// update may be lost.
+func TestWriteConfigConcurrent(t *testing.T) {
+	t.Setenv(config.HomeEnvVar, t.TempDir())
+	t.Setenv(config.LayoutEnvVar, "")
+	ops := &sumdbOps{config: &sumdbConfig{Key: "test+key"}, dir: t.TempDir()}
+	const file = "sum.example.com/latest"
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				old, err := ops.ReadConfig(file)
+				if err != nil {
+					errs <- err
+					return
+				}
+				n, _ := strconv.Atoi(string(old))
+				err = ops.WriteConfig(file, old, []byte(strconv.Itoa(n+1)))
+				if !errors.Is(err, sumdb.ErrWriteConflict) {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := ops.ReadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != strconv.Itoa(writers) {
+		t.Errorf("counter = %q after %d increments", data, writers)
+	}
+	entries, err := os.ReadDir(filepath.Dir(ops.configPath(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+
+	// A stale old value is a conflict
+	if err := ops.WriteConfig(file, []byte("0"), []byte("x")); !errors.Is(err, sumdb.ErrWriteConflict) {
+		t.Errorf("WriteConfig with stale old value = %v, want ErrWriteConflict", err)
+	}
+}
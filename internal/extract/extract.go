@@ -0,0 +1,519 @@
+// Package extract unpacks SDK archives (.tar.gz and .zip) into a
+// destination directory.
+//
+// Archives may come from mirrors that are not fully trusted, so extraction
+// refuses entries that would land outside the destination: absolute or
+// ".."-relative names, symlinks pointing outside directly or through other
+// symlinks, hardlinks pointing outside, and entries written through
+// previously extracted symlinks. File modes and modification times are
+// preserved; setuid, setgid and sticky bits are dropped.
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// UnsafePathError is returned for archive entries that would escape the
+// destination directory.
+type UnsafePathError struct {
+	Name   string
+	Reason string
+}
+
+func (e *UnsafePathError) Error() string {
+	return fmt.Sprintf("refusing to extract %q: %s", e.Name, e.Reason)
+}
+
+// TarGz extracts the gzip-compressed tar archive read from r into destDir.
+// Only entries below prefix (e.g. "go/") are extracted, with prefix removed.
+func TarGz(r io.Reader, destDir, prefix string) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = gzr.Close()
+	}()
+	return Tar(gzr, destDir, prefix)
+}
+
+// Tar extracts the uncompressed tar archive read from r into destDir, like
+// TarGz.
+func Tar(r io.Reader, destDir, prefix string) error {
+	x := &extractor{destDir: destDir, prefix: prefix}
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rel, ok, err := x.relPath(header.Name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(rel, header.FileInfo().Mode(), header.ModTime)
+		case tar.TypeReg:
+			err = x.file(rel, header.FileInfo().Mode(), header.ModTime, tr)
+		case tar.TypeSymlink:
+			err = x.symlink(rel, header.Linkname)
+		case tar.TypeLink:
+			err = x.hardlink(rel, header.Linkname)
+		case tar.TypeXGlobalHeader:
+			// PAX metadata, nothing to extract
+		default:
+			err = &UnsafePathError{Name: header.Name, Reason: fmt.Sprintf("unsupported entry type %q", header.Typeflag)}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return x.finish()
+}
+
+// creatorUnix is the zip "version made by" host of archives that record
+// Unix file modes.
+const creatorUnix = 3
+
+// Zip extracts the zip archive at archivePath into destDir. Only entries
+// below prefix are extracted, with prefix removed. Archives created on Unix
+// keep their file modes; for others, such as Go module zips, directories
+// are created 0755 and files 0644.
+func Zip(archivePath, destDir, prefix string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+
+	x := &extractor{destDir: destDir, prefix: prefix}
+	for _, f := range zr.File {
+		rel, ok, err := x.relPath(f.Name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		mode := f.Mode()
+		if f.CreatorVersion>>8 != creatorUnix {
+			mode = 0644
+			if f.FileInfo().IsDir() {
+				mode = os.ModeDir | 0755
+			}
+		}
+
+		switch {
+		case mode.IsDir():
+			err = x.dir(rel, mode, f.Modified)
+		case mode&os.ModeSymlink != 0:
+			err = x.zipSymlink(rel, f)
+		case mode.IsRegular():
+			err = x.zipFile(rel, mode, f)
+		default:
+			err = &UnsafePathError{Name: f.Name, Reason: fmt.Sprintf("unsupported file mode %v", mode)}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return x.finish()
+}
+
+func (x *extractor) zipFile(rel string, mode os.FileMode, f *zip.File) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	return x.file(rel, mode, f.Modified, r)
+}
+
+// zipSymlink creates a symlink entry, whose target is stored as its content.
+func (x *extractor) zipSymlink(rel string, f *zip.File) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	target, err := io.ReadAll(io.LimitReader(r, 4096))
+	if err != nil {
+		return err
+	}
+	return x.symlink(rel, string(target))
+}
+
+// ErrNotFound is returned by ReadTarGz and ReadZip when the archive has no
+// such file.
+var ErrNotFound = errors.New("file not found in archive")
+
+// ReadTarGz returns the contents of the regular file name in the
+// gzip-compressed tar archive read from r, e.g. "go/VERSION".
+func ReadTarGz(r io.Reader, name string) ([]byte, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = gzr.Close()
+	}()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && path.Clean(header.Name) == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// ReadZip returns the contents of the regular file name in the zip archive
+// at archivePath, e.g. "go/VERSION".
+func ReadZip(archivePath, name string) ([]byte, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+
+	for _, f := range zr.File {
+		if f.Mode().IsRegular() && path.Clean(f.Name) == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				_ = rc.Close()
+			}()
+			return io.ReadAll(rc)
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+}
+
+// WalkTarGz calls fn with the cleaned name of each entry of the
+// gzip-compressed tar archive read from r, until fn returns false.
+func WalkTarGz(r io.Reader, fn func(name string) bool) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = gzr.Close()
+	}()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fn(path.Clean(header.Name)) {
+			return nil
+		}
+	}
+}
+
+// WalkZip calls fn with the cleaned name of each entry of the zip archive
+// at archivePath, until fn returns false.
+func WalkZip(archivePath string, fn func(name string) bool) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+
+	for _, f := range zr.File {
+		if !fn(path.Clean(f.Name)) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// extractor holds the state of a single extraction.
+type extractor struct {
+	destDir string
+	prefix  string
+
+	// directories whose final mode and mtime are applied once all their
+	// contents have been written
+	dirs []pendingDir
+	// symlinks created, checked once the archive is complete
+	links []string
+}
+
+type pendingDir struct {
+	path    string
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// relPath validates an archive name and returns it relative to destDir, with
+// the prefix stripped. ok is false for entries outside the prefix.
+func (x *extractor) relPath(name string) (rel string, ok bool, err error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", false, &UnsafePathError{Name: name, Reason: "absolute path"}
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", false, &UnsafePathError{Name: name, Reason: "path contains '..'"}
+		}
+	}
+
+	if !strings.HasPrefix(name, x.prefix) {
+		// Skip files not in the prefix (unlikely for official builds)
+		return "", false, nil
+	}
+	rel = path.Clean(strings.TrimPrefix(name, x.prefix))
+	if rel == "." || rel == "" {
+		return "", false, nil
+	}
+	return rel, true, nil
+}
+
+// target returns the destination path for rel after checking that none of
+// its parent directories is a symlink, which could redirect the write.
+func (x *extractor) target(rel string) (string, error) {
+	parts := strings.Split(rel, "/")
+	cur := x.destDir
+	for _, part := range parts[:len(parts)-1] {
+		cur = filepath.Join(cur, part)
+		info, err := os.Lstat(cur)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", &UnsafePathError{Name: rel, Reason: "parent directory is a symlink"}
+		}
+	}
+	return filepath.Join(x.destDir, filepath.FromSlash(rel)), nil
+}
+
+func (x *extractor) dir(rel string, mode os.FileMode, modTime time.Time) error {
+	target, err := x.target(rel)
+	if err != nil {
+		return err
+	}
+	// Keep directories writable until everything inside has been extracted
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	x.dirs = append(x.dirs, pendingDir{path: target, mode: mode.Perm(), modTime: modTime})
+	return nil
+}
+
+func (x *extractor) file(rel string, mode os.FileMode, modTime time.Time, r io.Reader) error {
+	target, err := x.prepare(rel)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// The umask may have narrowed the mode given to OpenFile
+	if err := os.Chmod(target, mode.Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(target, modTime, modTime)
+}
+
+func (x *extractor) symlink(rel, linkname string) error {
+	linkname = strings.ReplaceAll(linkname, `\`, "/")
+	if path.IsAbs(linkname) || filepath.IsAbs(linkname) || filepath.VolumeName(linkname) != "" {
+		return &UnsafePathError{Name: rel, Reason: "symlink to absolute path " + linkname}
+	}
+	resolved := path.Join(path.Dir(rel), linkname)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return &UnsafePathError{Name: rel, Reason: "symlink escapes destination: " + linkname}
+	}
+
+	target, err := x.prepare(rel)
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(filepath.FromSlash(linkname), target); err != nil {
+		return err
+	}
+	x.links = append(x.links, rel)
+	return nil
+}
+
+// maxLinkHops bounds the symlinks followed when resolving a path, like the
+// kernel's ELOOP limit.
+const maxLinkHops = 40
+
+// checkLink resolves the symlink rel through all symlinks extracted so far
+// and fails if any step leaves the destination. The lexical check in
+// symlink misses chains such as "x -> a/link/.." with "a/link -> ..".
+func (x *extractor) checkLink(rel string) error {
+	todo := strings.Split(rel, "/")
+	var cur []string
+	hops := 0
+	for len(todo) > 0 {
+		part := todo[0]
+		todo = todo[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(cur) == 0 {
+				return &UnsafePathError{Name: rel, Reason: "symlink escapes destination through other symlinks"}
+			}
+			cur = cur[:len(cur)-1]
+			continue
+		}
+		cur = append(cur, part)
+
+		p := filepath.Join(append([]string{x.destDir}, cur...)...)
+		info, err := os.Lstat(p)
+		if os.IsNotExist(err) {
+			// Dangling: the rest of the path is resolved lexically
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		if hops++; hops > maxLinkHops {
+			return &UnsafePathError{Name: rel, Reason: "too many levels of symlinks"}
+		}
+		linkname, err := os.Readlink(p)
+		if err != nil {
+			return err
+		}
+		linkname = filepath.ToSlash(linkname)
+		if path.IsAbs(linkname) || filepath.IsAbs(linkname) {
+			return &UnsafePathError{Name: rel, Reason: "symlink to absolute path " + linkname}
+		}
+		// Continue from the directory holding the link
+		cur = cur[:len(cur)-1]
+		todo = append(strings.Split(linkname, "/"), todo...)
+	}
+	return nil
+}
+
+func (x *extractor) hardlink(rel, linkname string) error {
+	// Hardlink names are archive paths, subject to the same rules as entries
+	src, ok, err := x.relPath(linkname)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &UnsafePathError{Name: rel, Reason: "hardlink to entry outside the archive root: " + linkname}
+	}
+	srcPath, err := x.target(src)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(srcPath)
+	if err != nil {
+		return fmt.Errorf("hardlink %s: %w", rel, err)
+	}
+	if !info.Mode().IsRegular() {
+		return &UnsafePathError{Name: rel, Reason: "hardlink to non-regular file " + linkname}
+	}
+
+	target, err := x.prepare(rel)
+	if err != nil {
+		return err
+	}
+	return os.Link(srcPath, target)
+}
+
+// prepare creates the parent directory of rel and removes any existing
+// non-directory entry at its path, so that duplicate archive entries replace
+// earlier ones instead of writing through them.
+func (x *extractor) prepare(rel string) (string, error) {
+	target, err := x.target(rel)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	if info, err := os.Lstat(target); err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("cannot replace directory %s with a file", rel)
+		}
+		if err := os.Remove(target); err != nil {
+			return "", err
+		}
+	}
+	return target, nil
+}
+
+// finish checks the extracted symlinks and applies directory modes and
+// times, deepest directories first so that setting a parent's mtime is not
+// undone by changes to its children.
+func (x *extractor) finish() error {
+	for _, link := range x.links {
+		if err := x.checkLink(link); err != nil {
+			return err
+		}
+	}
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		d := x.dirs[i]
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			return err
+		}
+		if err := os.Chtimes(d.path, d.modTime, d.modTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
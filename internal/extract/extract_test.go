@@ -0,0 +1,415 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var modTime = time.Date(2024, 2, 6, 12, 0, 0, 0, time.UTC)
+
+// entry is an archive entry. Typeflag defaults to a regular file.
+type entry struct {
+	Name     string
+	Typeflag byte
+	Linkname string
+	Body     string
+	Mode     int64
+}
+
+func tarArchive(t *testing.T, entries []entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		h := &tar.Header{
+			Name:     e.Name,
+			Typeflag: e.Typeflag,
+			Linkname: e.Linkname,
+			Mode:     e.Mode,
+			ModTime:  modTime,
+			Size:     int64(len(e.Body)),
+		}
+		if h.Typeflag == 0 {
+			h.Typeflag = tar.TypeReg
+		}
+		if h.Typeflag != tar.TypeReg {
+			h.Size = 0
+		}
+		if h.Mode == 0 {
+			h.Mode = 0644
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.Body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, entries []entry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.Name, Method: zip.Deflate, Modified: modTime}
+		mode := os.FileMode(0644)
+		body := e.Body
+		if e.Typeflag == tar.TypeSymlink {
+			mode = os.ModeSymlink | 0777
+			body = e.Linkname
+		}
+		h.SetMode(mode)
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// extractTo extracts into a "root" directory inside a fresh temporary
+// directory, so escapes land in its parent instead of somewhere shared.
+func extractTo(t *testing.T, format string, entries []entry) (string, error) {
+	t.Helper()
+	dest := filepath.Join(t.TempDir(), "root")
+	if err := os.Mkdir(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	switch format {
+	case "tar":
+		return dest, Tar(bytes.NewReader(tarArchive(t, entries)), dest, "go/")
+	case "zip":
+		return dest, Zip(zipArchive(t, entries), dest, "go/")
+	}
+	t.Fatalf("unknown format %s", format)
+	return "", nil
+}
+
+func TestUnsafeArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []string
+		entries []entry
+	}{
+		{
+			name:    "dot-dot name",
+			formats: []string{"tar", "zip"},
+			entries: []entry{{Name: "go/../evil", Body: "x"}},
+		},
+		{
+			name:    "dot-dot before prefix",
+			formats: []string{"tar", "zip"},
+			entries: []entry{{Name: "../go/evil", Body: "x"}},
+		},
+		{
+			name:    "absolute name",
+			formats: []string{"tar", "zip"},
+			entries: []entry{{Name: "/go/evil", Body: "x"}},
+		},
+		{
+			name:    "backslash dot-dot name",
+			formats: []string{"tar", "zip"},
+			entries: []entry{{Name: `go\..\..\evil`, Body: "x"}},
+		},
+		{
+			name:    "symlink escape",
+			formats: []string{"tar", "zip"},
+			entries: []entry{{Name: "go/link", Typeflag: tar.TypeSymlink, Linkname: "../.."}},
+		},
+		{
+			name:    "absolute symlink",
+			formats: []string{"tar", "zip"},
+			entries: []entry{{Name: "go/link", Typeflag: tar.TypeSymlink, Linkname: "/etc"}},
+		},
+		{
+			name:    "chained symlink escape",
+			formats: []string{"tar", "zip"},
+			entries: []entry{
+				{Name: "go/a/link", Typeflag: tar.TypeSymlink, Linkname: ".."},
+				{Name: "go/x", Typeflag: tar.TypeSymlink, Linkname: "a/link/.."},
+			},
+		},
+		{
+			name:    "chained symlink escape, link target created later",
+			formats: []string{"tar", "zip"},
+			entries: []entry{
+				{Name: "go/x", Typeflag: tar.TypeSymlink, Linkname: "a/link/.."},
+				{Name: "go/a/link", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			},
+		},
+		{
+			name:    "write through symlinked parent",
+			formats: []string{"tar", "zip"},
+			entries: []entry{
+				{Name: "go/sub/", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "go/dir", Typeflag: tar.TypeSymlink, Linkname: "sub"},
+				{Name: "go/dir/file", Body: "x"},
+			},
+		},
+		{
+			name:    "hardlink outside root",
+			formats: []string{"tar"},
+			entries: []entry{{Name: "go/h", Typeflag: tar.TypeLink, Linkname: "other/file"}},
+		},
+		{
+			name:    "hardlink dot-dot",
+			formats: []string{"tar"},
+			entries: []entry{{Name: "go/h", Typeflag: tar.TypeLink, Linkname: "go/../../etc/passwd"}},
+		},
+		{
+			name:    "hardlink to symlink",
+			formats: []string{"tar"},
+			entries: []entry{
+				{Name: "go/link", Typeflag: tar.TypeSymlink, Linkname: "file"},
+				{Name: "go/h", Typeflag: tar.TypeLink, Linkname: "go/link"},
+			},
+		},
+		{
+			name:    "hardlink to directory",
+			formats: []string{"tar"},
+			entries: []entry{
+				{Name: "go/dir/", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "go/h", Typeflag: tar.TypeLink, Linkname: "go/dir"},
+			},
+		},
+		{
+			name:    "device file",
+			formats: []string{"tar"},
+			entries: []entry{{Name: "go/dev", Typeflag: tar.TypeChar}},
+		},
+	}
+	for _, tt := range tests {
+		for _, format := range tt.formats {
+			t.Run(tt.name+"/"+format, func(t *testing.T) {
+				dest, err := extractTo(t, format, tt.entries)
+				var unsafe *UnsafePathError
+				if !errors.As(err, &unsafe) {
+					t.Fatalf("err = %v, want UnsafePathError", err)
+				}
+				if _, err := os.Lstat(filepath.Join(filepath.Dir(dest), "evil")); err == nil {
+					t.Error("file written outside the destination")
+				}
+			})
+		}
+	}
+}
+
+func TestSafeSymlinks(t *testing.T) {
+	for _, format := range []string{"tar", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			dest, err := extractTo(t, format, []entry{
+				{Name: "go/lib/file", Body: "data"},
+				{Name: "go/bin/link", Typeflag: tar.TypeSymlink, Linkname: "../lib/file"},
+				{Name: "go/up", Typeflag: tar.TypeSymlink, Linkname: "bin/.."},
+				{Name: "go/dangling", Typeflag: tar.TypeSymlink, Linkname: "missing/file"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(filepath.Join(dest, "bin", "link"))
+			if err != nil || string(data) != "data" {
+				t.Errorf("bin/link = %q, %v; want %q", data, err, "data")
+			}
+		})
+	}
+}
+
+func TestHardlink(t *testing.T) {
+	dest, err := extractTo(t, "tar", []entry{
+		{Name: "go/file", Body: "data"},
+		{Name: "go/h", Typeflag: tar.TypeLink, Linkname: "go/file"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.Stat(filepath.Join(dest, "file"))
+	b, _ := os.Stat(filepath.Join(dest, "h"))
+	if a == nil || b == nil || !os.SameFile(a, b) {
+		t.Error("h is not a hardlink of file")
+	}
+}
+
+func TestDuplicateEntries(t *testing.T) {
+	for _, format := range []string{"tar", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			outside := filepath.Join(t.TempDir(), "outside")
+			if err := os.WriteFile(outside, []byte("keep"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			dest, err := extractTo(t, format, []entry{
+				{Name: "go/file", Body: "first"},
+				{Name: "go/file", Body: "second"},
+				// A later file replaces a symlink instead of writing through it
+				{Name: "go/link", Typeflag: tar.TypeSymlink, Linkname: "file"},
+				{Name: "go/link", Body: "replaced"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range map[string]string{"file": "second", "link": "replaced"} {
+				data, err := os.ReadFile(filepath.Join(dest, name))
+				if err != nil || string(data) != want {
+					t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+				}
+			}
+			if info, _ := os.Lstat(filepath.Join(dest, "link")); info == nil || !info.Mode().IsRegular() {
+				t.Error("link was not replaced by a regular file")
+			}
+		})
+	}
+}
+
+func TestDuplicateDirectoryReplacedByFile(t *testing.T) {
+	_, err := extractTo(t, "tar", []entry{
+		{Name: "go/dir/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "go/dir", Body: "x"},
+	})
+	if err == nil {
+		t.Fatal("replacing a directory with a file succeeded")
+	}
+}
+
+func TestModesAndTimes(t *testing.T) {
+	dest, err := extractTo(t, "tar", []entry{
+		{Name: "go/bin/", Typeflag: tar.TypeDir, Mode: 0750},
+		{Name: "go/bin/tool", Body: "#!/bin/sh\n", Mode: 0755},
+		{Name: "go/bin/suid", Body: "x", Mode: 04755},
+		{Name: "go/bin/sgid", Body: "x", Mode: 02755},
+		{Name: "go/readonly", Body: "x", Mode: 0444},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]os.FileMode{
+		"bin":      os.ModeDir | 0750,
+		"bin/tool": 0755,
+		"bin/suid": 0755,
+		"bin/sgid": 0755,
+		"readonly": 0444,
+	} {
+		info, err := os.Lstat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != want {
+			t.Errorf("%s: mode = %v, want %v", name, info.Mode(), want)
+		}
+		if !info.ModTime().Equal(modTime) {
+			t.Errorf("%s: mtime = %v, want %v", name, info.ModTime(), modTime)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	dest, err := extractTo(t, "zip", []entry{
+		{Name: "go/VERSION", Body: "go1.24.0"},
+		{Name: "other/file", Body: "x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "VERSION")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "other")); err == nil {
+		t.Error("entry outside the prefix was extracted")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	entries := []entry{
+		{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "go/VERSION", Body: "go1.24.0\ntime 2025-02-10T23:19:25Z\n"},
+	}
+	var tgz bytes.Buffer
+	gw := gzip.NewWriter(&tgz)
+	if _, err := gw.Write(tarArchive(t, entries)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := zipArchive(t, entries)
+
+	read := map[string]func(name string) ([]byte, error){
+		"tar.gz": func(name string) ([]byte, error) { return ReadTarGz(bytes.NewReader(tgz.Bytes()), name) },
+		"zip":    func(name string) ([]byte, error) { return ReadZip(zipPath, name) },
+	}
+	for format, readFile := range read {
+		t.Run(format, func(t *testing.T) {
+			data, err := readFile("go/VERSION")
+			if err != nil || !bytes.HasPrefix(data, []byte("go1.24.0\n")) {
+				t.Errorf("go/VERSION = %q, %v", data, err)
+			}
+			for _, name := range []string{"go/MISSING", "go"} {
+				if _, err := readFile(name); !errors.Is(err, ErrNotFound) {
+					t.Errorf("%s: err = %v, want ErrNotFound", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	entries := []entry{
+		{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "go/VERSION", Body: "go1.24.0\n"},
+		{Name: "go/pkg/tool/linux_arm64/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "go/pkg/tool/linux_arm64/vet", Body: "vet"},
+	}
+	var tgz bytes.Buffer
+	gw := gzip.NewWriter(&tgz)
+	if _, err := gw.Write(tarArchive(t, entries)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := zipArchive(t, entries)
+
+	walk := map[string]func(fn func(string) bool) error{
+		"tar.gz": func(fn func(string) bool) error { return WalkTarGz(bytes.NewReader(tgz.Bytes()), fn) },
+		"zip":    func(fn func(string) bool) error { return WalkZip(zipPath, fn) },
+	}
+	for format, walkFn := range walk {
+		t.Run(format, func(t *testing.T) {
+			var names []string
+			err := walkFn(func(name string) bool {
+				names = append(names, name)
+				return name != "go/pkg/tool/linux_arm64"
+			})
+			want := []string{"go", "go/VERSION", "go/pkg/tool/linux_arm64"}
+			if err != nil || !slices.Equal(names, want) {
+				t.Errorf("walked %q, %v; want %q", names, err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// Package goversion parses and orders Go release versions such as "1.9.7",
+// "1.20", "1.21.0", "1.24rc1" and "1.24beta2".
+package goversion
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Kind is the release kind of a version. Kinds are ordered: a beta comes
+// before a release candidate, which comes before the final release.
+type Kind int
+
+const (
+	Beta Kind = iota
+	RC
+	Release
+)
+
+// Version is a parsed Go release version.
+type Version struct {
+	Major, Minor, Patch int
+	Kind                Kind
+	// Pre is the beta or release candidate number, 0 for final releases.
+	Pre int
+}
+
+// Parse parses a Go release version, with or without the "go" prefix.
+// Releases before Go 1.21 are named without a patch number for the first
+// release of a line ("1.20"), which is the same as "1.20.0".
+func Parse(s string) (Version, error) {
+	v := Version{Kind: Release}
+	rest := strings.TrimPrefix(s, "go")
+
+	for _, pre := range []struct {
+		marker string
+		kind   Kind
+	}{{"beta", Beta}, {"rc", RC}} {
+		i := strings.Index(rest, pre.marker)
+		if i < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(rest[i+len(pre.marker):])
+		if err != nil || n < 1 {
+			return Version{}, fmt.Errorf("invalid Go version %q", s)
+		}
+		v.Kind, v.Pre = pre.kind, n
+		rest = rest[:i]
+		break
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid Go version %q", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || p != strconv.Itoa(n) {
+			return Version{}, fmt.Errorf("invalid Go version %q", s)
+		}
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		}
+	}
+	return v, nil
+}
+
+// IsValid reports whether s is a Go release version.
+func IsValid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// IsStable reports whether s is a final release, not a beta or release
+// candidate. Invalid versions are not stable.
+func IsStable(s string) bool {
+	v, err := Parse(s)
+	return err == nil && v.Kind == Release
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is older than, the
+// same as or newer than w.
+func (v Version) Compare(w Version) int {
+	for _, d := range [...]int{
+		v.Major - w.Major,
+		v.Minor - w.Minor,
+		v.Patch - w.Patch,
+		int(v.Kind - w.Kind),
+		v.Pre - w.Pre,
+	} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Compare orders two version strings, returning -1, 0 or +1. Invalid
+// versions, such as names of source builds, sort after all valid ones and
+// among themselves by string.
+func Compare(a, b string) int {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+	switch {
+	case errA == nil && errB == nil:
+		return va.Compare(vb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Sort sorts versions from oldest to newest. Versions that compare equal,
+// such as "1.20" and "1.20.0", keep their order.
+func Sort(versions []string) {
+	slices.SortStableFunc(versions, Compare)
+}
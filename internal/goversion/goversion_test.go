@@ -0,0 +1,104 @@
+package goversion
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"1.24.0", Version{Major: 1, Minor: 24, Patch: 0, Kind: Release}},
+		{"go1.24.3", Version{Major: 1, Minor: 24, Patch: 3, Kind: Release}},
+		{"1.20", Version{Major: 1, Minor: 20, Kind: Release}},
+		{"1.9.7", Version{Major: 1, Minor: 9, Patch: 7, Kind: Release}},
+		{"1.24rc1", Version{Major: 1, Minor: 24, Kind: RC, Pre: 1}},
+		{"go1.21rc4", Version{Major: 1, Minor: 21, Kind: RC, Pre: 4}},
+		{"1.24beta2", Version{Major: 1, Minor: 24, Kind: Beta, Pre: 2}},
+		{"1", Version{Major: 1, Kind: Release}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{
+		"", "go", "latest", "tip", "1.24.0.1", "1.24.x", "1..24", "1.024",
+		"1.24rc", "1.24rc0", "1.24beta", "1.24-rc1", "v1.24.0", "1.24.0 ",
+		"-1.24", "1.24rc1beta2", "source-1a2b3c4d5e",
+	} {
+		if v, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) = %+v, want error", s, v)
+		}
+		if IsValid(s) {
+			t.Errorf("IsValid(%q) = true", s)
+		}
+	}
+}
+
+func TestIsStable(t *testing.T) {
+	for s, want := range map[string]bool{
+		"1.24.0":    true,
+		"1.20":      true,
+		"1.24rc1":   false,
+		"1.24beta1": false,
+		"latest":    false,
+	} {
+		if got := IsStable(s); got != want {
+			t.Errorf("IsStable(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.9", "1.10", -1},
+		{"1.9.7", "1.10", -1},
+		{"1.9.7", "1.25.0", -1},
+		{"1.20", "1.20.0", 0},
+		{"go1.20", "1.20.0", 0},
+		{"1.20", "1.20.1", -1},
+		{"1.24beta1", "1.24beta2", -1},
+		{"1.24beta2", "1.24rc1", -1},
+		{"1.24rc1", "1.24rc2", -1},
+		{"1.24rc2", "1.24.0", -1},
+		{"1.24rc1", "1.23.9", 1},
+		{"1.21rc2", "1.21.0", -1},
+		{"1.24.0", "1.24.0", 0},
+		{"2.0", "1.99.99", 1},
+		// Invalid versions sort after valid ones, by string
+		{"1.24.0", "tip-abc", -1},
+		{"tip-abc", "1.24.0", 1},
+		{"tip-abc", "tip-abd", -1},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Compare(tt.b, tt.a); got != -tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	versions := []string{"1.25.0", "tip-abc", "1.9.7", "1.24.0", "1.20.0", "1.24rc1", "1.20", "1.10", "1.24beta1"}
+	want := []string{"1.9.7", "1.10", "1.20.0", "1.20", "1.24beta1", "1.24rc1", "1.24.0", "1.25.0", "tip-abc"}
+	Sort(versions)
+	if !slices.Equal(versions, want) {
+		t.Errorf("Sort = %q, want %q", versions, want)
+	}
+}
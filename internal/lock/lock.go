@@ -0,0 +1,118 @@
+// Package lock provides inter-process file locks in the vg locks directory, so that
+// concurrent vg processes do not download, extract, remove or activate the
+// same SDK at the same time.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+// Activation is the name of the lock guarding the global 'current*' symlinks
+// and virtual environments.
+const Activation = "activation"
+
+// Source is the name of the lock guarding the Go repository clone used for
+// source builds.
+const Source = "source"
+
+// Shims is the name of the lock guarding the shims directory.
+const Shims = "shims"
+
+// Sumdb is the name of the lock guarding the checksum database state.
+const Sumdb = "sumdb"
+
+// pollInterval is how often a blocked process retries the lock.
+const pollInterval = 100 * time.Millisecond
+
+// ErrTimeout is returned when a lock could not be acquired in time.
+var ErrTimeout = errors.New("timed out waiting for lock")
+
+// SDK returns the name of the lock guarding a single SDK version.
+func SDK(version string) string {
+	return "sdk-" + version
+}
+
+// Lock is a held lock.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes the named lock, waiting up to timeout for another process to
+// release it. A timeout of zero waits indefinitely. While waiting, waiting is
+// called once with a description of the process holding the lock.
+func Acquire(name string, timeout time.Duration, waiting func(holder string)) (*Lock, error) {
+	locksDir, err := config.GetLocksDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(locksDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating locks directory: %w", err)
+	}
+
+	path := filepath.Join(locksDir, name+".lock")
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock %s: %w", path, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	notified := false
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("error locking %s: %w", path, err)
+		}
+		if ok {
+			break
+		}
+
+		holder := readHolder(path)
+		if !notified && waiting != nil {
+			waiting(holder)
+			notified = true
+		}
+		if timeout > 0 && time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("%w %s (held by %s)", ErrTimeout, name, holder)
+		}
+		time.Sleep(pollInterval)
+	}
+
+	// Record ourselves as the holder for processes waiting on us
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(fmt.Sprintf("pid %d: %s\n", os.Getpid(), strings.Join(os.Args, " "))), 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release releases the lock. Locks are also released when the process exits.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	_ = l.f.Truncate(0)
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// readHolder describes the process recorded in the lock file.
+func readHolder(path string) string {
+	data, err := os.ReadFile(path)
+	holder := strings.TrimSpace(string(data))
+	if err != nil || holder == "" {
+		return "another vg process"
+	}
+	return holder
+}
@@ -0,0 +1,129 @@
+//go:build unix
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fun7257/vg/internal/config"
+)
+
+func setup(t *testing.T) {
+	t.Helper()
+	t.Setenv(config.HomeEnvVar, t.TempDir())
+	t.Setenv(config.LayoutEnvVar, "")
+}
+
+// flock locks belong to an open file description, so two Acquire calls in
+// one process contend like two processes do.
+func TestAcquireContention(t *testing.T) {
+	setup(t)
+	held, err := Acquire("test", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var holders []string
+	start := time.Now()
+	_, err = Acquire("test", 3*pollInterval, func(holder string) {
+		holders = append(holders, holder)
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Acquire on a held lock = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed < 3*pollInterval {
+		t.Errorf("gave up after %v, before the timeout", elapsed)
+	}
+
+	want := fmt.Sprintf("pid %d: %s", os.Getpid(), strings.Join(os.Args, " "))
+	if len(holders) != 1 || holders[0] != want {
+		t.Errorf("waiting called with %q, want once with %q", holders, want)
+	}
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not name the holder", err)
+	}
+
+	// Other locks are independent
+	other, err := Acquire(SDK("1.24.0"), pollInterval, nil)
+	if err != nil {
+		t.Fatalf("Acquire of another lock: %v", err)
+	}
+	if err := other.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Acquire("test", pollInterval, func(string) {
+		t.Error("waited for a released lock")
+	})
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	if err := again.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireWaitsForRelease(t *testing.T) {
+	setup(t)
+	held, err := Acquire("test", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(2 * pollInterval)
+		_ = held.Release()
+	}()
+
+	waited := false
+	l, err := Acquire("test", 0, func(string) { waited = true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Release()
+	}()
+	if !waited {
+		t.Error("Acquire did not wait for the holder")
+	}
+}
+
+func TestReadHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.lock")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A lock file emptied by Release, or not written yet
+	if got := readHolder(path); got != "another vg process" {
+		t.Errorf("readHolder of an empty file = %q", got)
+	}
+	if got := readHolder(path + ".missing"); got != "another vg process" {
+		t.Errorf("readHolder of a missing file = %q", got)
+	}
+}
+
+func TestReleaseTwice(t *testing.T) {
+	setup(t)
+	l, err := Acquire("test", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Release(); err != nil {
+		t.Errorf("second Release = %v", err)
+	}
+	var nilLock *Lock
+	if err := nilLock.Release(); err != nil {
+		t.Errorf("Release of nil lock = %v", err)
+	}
+}
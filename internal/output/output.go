@@ -0,0 +1,187 @@
+// Package output defines the structured output of vg commands selected with
+// --output json or --output yaml.
+//
+// Every document starts with the schema version and its kind. Within a
+// schema version fields are only ever added; renaming or removing a field,
+// or changing its meaning, increments SchemaVersion.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the documents defined in this package.
+const SchemaVersion = 1
+
+// Format is an output format.
+type Format string
+
+const (
+	// Table is the human-readable output, the default.
+	Table Format = "table"
+	// JSON writes one indented JSON document.
+	JSON Format = "json"
+	// YAML writes one YAML document.
+	YAML Format = "yaml"
+)
+
+// ParseFormat validates an --output value.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Table, JSON, YAML:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid output format %q (expected %s, %s or %s)", s, Table, JSON, YAML)
+}
+
+// Document kinds.
+const (
+	KindVersionList       = "VersionList"
+	KindStatus            = "Status"
+	KindEnvList           = "EnvList"
+	KindRemoteVersionList = "RemoteVersionList"
+	KindConfigList        = "ConfigList"
+	KindError             = "Error"
+)
+
+// Header starts every document.
+type Header struct {
+	SchemaVersion int    `json:"schema_version" yaml:"schema_version"`
+	Kind          string `json:"kind" yaml:"kind"`
+}
+
+// NewHeader returns the header of a document of kind.
+func NewHeader(kind string) Header {
+	return Header{SchemaVersion: SchemaVersion, Kind: kind}
+}
+
+// Active names the active version and virtual environment. Env is empty
+// when the global environment of the version is used.
+type Active struct {
+	Version string `json:"version" yaml:"version"`
+	Env     string `json:"env" yaml:"env"`
+	// Session is true if the shell overrides the global selection.
+	Session bool `json:"session" yaml:"session"`
+}
+
+// Paths are the Go environment of a version or virtual environment.
+type Paths struct {
+	Goroot     string `json:"goroot" yaml:"goroot"`
+	Gopath     string `json:"gopath" yaml:"gopath"`
+	Gocache    string `json:"gocache" yaml:"gocache"`
+	Goenv      string `json:"goenv" yaml:"goenv"`
+	Gomodcache string `json:"gomodcache" yaml:"gomodcache"`
+}
+
+// Env is a virtual environment.
+type Env struct {
+	Name   string `json:"name" yaml:"name"`
+	Remark string `json:"remark" yaml:"remark"`
+	Active bool   `json:"active" yaml:"active"`
+}
+
+// Version is an installed SDK.
+type Version struct {
+	// Name is the SDK name, e.g. "1.24.0" or "1.24.0.linux-arm64".
+	Name string `json:"name" yaml:"name"`
+	// Version is the Go version, e.g. "1.24.0" or "tip-1a2b3c4d5e".
+	Version string `json:"version" yaml:"version"`
+	// Platform is the GOOS/GOARCH the SDK is built for.
+	Platform string `json:"platform" yaml:"platform"`
+	Native   bool   `json:"native" yaml:"native"`
+	// Complete is false for leftovers of interrupted installs.
+	Complete    bool       `json:"complete" yaml:"complete"`
+	InstalledAt *time.Time `json:"installed_at" yaml:"installed_at"`
+	// SizeBytes is the size on disk recorded at install time, 0 if unknown.
+	SizeBytes int64  `json:"size_bytes" yaml:"size_bytes"`
+	Active    bool   `json:"active" yaml:"active"`
+	Goroot    string `json:"goroot" yaml:"goroot"`
+	Envs      []Env  `json:"envs" yaml:"envs"`
+}
+
+// VersionList is written by 'vg list'.
+type VersionList struct {
+	Header   `yaml:",inline"`
+	Active   *Active   `json:"active" yaml:"active"`
+	Versions []Version `json:"versions" yaml:"versions"`
+}
+
+// Status is written by 'vg status'. Active and Paths are nil if no version
+// is active.
+type Status struct {
+	Header `yaml:",inline"`
+	Active *Active `json:"active" yaml:"active"`
+	Remark string  `json:"remark" yaml:"remark"`
+	Paths  *Paths  `json:"paths" yaml:"paths"`
+}
+
+// EnvList is written by 'vg env list'.
+type EnvList struct {
+	Header  `yaml:",inline"`
+	Version string `json:"version" yaml:"version"`
+	Envs    []Env  `json:"envs" yaml:"envs"`
+}
+
+// RemoteVersion is a release available for installation.
+type RemoteVersion struct {
+	Version   string `json:"version" yaml:"version"`
+	Stable    bool   `json:"stable" yaml:"stable"`
+	Installed bool   `json:"installed" yaml:"installed"`
+}
+
+// RemoteVersionList is written by 'vg ls-remote'.
+type RemoteVersionList struct {
+	Header   `yaml:",inline"`
+	Platform string          `json:"platform" yaml:"platform"`
+	Versions []RemoteVersion `json:"versions" yaml:"versions"`
+}
+
+// Setting is the effective value of a configuration setting.
+type Setting struct {
+	Key string `json:"key" yaml:"key"`
+	// Value is a string, a list of strings for list settings, or null if
+	// the setting is unset and has no default.
+	Value any `json:"value" yaml:"value"`
+	// Source is "default", "file" or "env".
+	Source string `json:"source" yaml:"source"`
+	EnvVar string `json:"env_var" yaml:"env_var"`
+}
+
+// ConfigList is written by 'vg config list'.
+type ConfigList struct {
+	Header   `yaml:",inline"`
+	File     string    `json:"file" yaml:"file"`
+	Settings []Setting `json:"settings" yaml:"settings"`
+}
+
+// Error is written instead of the command's document when a command fails
+// with --output json or yaml.
+type Error struct {
+	Header   `yaml:",inline"`
+	Message  string `json:"message" yaml:"message"`
+	Hint     string `json:"hint" yaml:"hint"`
+	ExitCode int    `json:"exit_code" yaml:"exit_code"`
+}
+
+// Write encodes doc to w in format, which must be JSON or YAML.
+func Write(w io.Writer, format Format, doc any) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("cannot write %s output", format)
+}
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var installedAt = time.Date(2025, 2, 11, 18, 4, 5, 0, time.UTC)
+
+// documents covers every kind with all optional parts present, plus the
+// nil cases scripts have to handle.
+var documents = map[string]any{
+	"version_list": VersionList{
+		Header: NewHeader(KindVersionList),
+		Active: &Active{Version: "1.24.0", Env: "web"},
+		Versions: []Version{
+			{
+				Name: "1.23.4", Version: "1.23.4", Platform: "linux/amd64", Native: true, Complete: true,
+				InstalledAt: &installedAt, SizeBytes: 254803968,
+				Goroot: "/home/user/.vg/sdks/1.23.4", Envs: []Env{},
+			},
+			{
+				Name: "1.24.0", Version: "1.24.0", Platform: "linux/amd64", Native: true, Complete: true,
+				InstalledAt: &installedAt, SizeBytes: 262144000, Active: true,
+				Goroot: "/home/user/.vg/sdks/1.24.0",
+				Envs: []Env{
+					{Name: "api", Remark: "backend"},
+					{Name: "web", Remark: "frontend: \"app\"", Active: true},
+				},
+			},
+			{
+				Name: "1.24.0.linux-arm64", Version: "1.24.0", Platform: "linux/arm64",
+				Goroot: "/home/user/.vg/sdks/1.24.0.linux-arm64", Envs: []Env{},
+			},
+		},
+	},
+	"version_list_empty": VersionList{
+		Header:   NewHeader(KindVersionList),
+		Versions: []Version{},
+	},
+	"status": Status{
+		Header: NewHeader(KindStatus),
+		Active: &Active{Version: "1.24.0", Env: "web", Session: true},
+		Remark: "frontend",
+		Paths: &Paths{
+			Goroot:     "/home/user/.vg/sdks/1.24.0",
+			Gopath:     "/home/user/.vg/envs/1.24.0/web/gopath",
+			Gocache:    "/home/user/.vg/envs/1.24.0/web/gocache",
+			Goenv:      "/home/user/.vg/envs/1.24.0/web/go.env",
+			Gomodcache: "/home/user/.vg/envs/1.24.0/web/gopath/pkg/mod",
+		},
+	},
+	"status_inactive": Status{
+		Header: NewHeader(KindStatus),
+	},
+	"env_list": EnvList{
+		Header:  NewHeader(KindEnvList),
+		Version: "1.24.0",
+		Envs: []Env{
+			{Name: "api", Remark: "backend"},
+			{Name: "web", Remark: "frontend", Active: true},
+		},
+	},
+	"remote_version_list": RemoteVersionList{
+		Header:   NewHeader(KindRemoteVersionList),
+		Platform: "linux/amd64",
+		Versions: []RemoteVersion{
+			{Version: "1.25rc1"},
+			{Version: "1.24.0", Stable: true, Installed: true},
+			{Version: "1.23.4", Stable: true},
+		},
+	},
+	"config_list": ConfigList{
+		Header: NewHeader(KindConfigList),
+		File:   "/home/user/.vg/config.toml",
+		Settings: []Setting{
+			{Key: "mirror", Value: []string{"https://go.dev/dl/"}, Source: "default", EnvVar: "VG_MIRROR"},
+			{Key: "default_version", Value: nil, Source: "default", EnvVar: "VG_DEFAULT_VERSION"},
+			{Key: "auto_install", Value: "never", Source: "env", EnvVar: "VG_AUTO_INSTALL"},
+		},
+	},
+	"error": Error{
+		Header:   NewHeader(KindError),
+		Message:  "go version 1.30.0 is not installed",
+		Hint:     "Run 'vg install 1.30.0' to install it.",
+		ExitCode: 3,
+	},
+}
+
+func TestGolden(t *testing.T) {
+	for name, doc := range documents {
+		for _, format := range []Format{JSON, YAML} {
+			t.Run(name+"/"+string(format), func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := Write(&buf, format, doc); err != nil {
+					t.Fatal(err)
+				}
+
+				golden := filepath.Join("testdata", name+"."+string(format)+".golden")
+				if *update {
+					if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run go test -update to create it)", err)
+				}
+				if !bytes.Equal(buf.Bytes(), want) {
+					t.Errorf("output differs from %s; the schema changed (bump SchemaVersion unless only fields were added, then run go test -update):\n%s", golden, buf.Bytes())
+				}
+			})
+		}
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Table, Status{}); err == nil {
+		t.Error("writing a table document succeeded")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"table", "json", "yaml"} {
+		if f, err := ParseFormat(s); err != nil || string(f) != s {
+			t.Errorf("ParseFormat(%q) = %q, %v", s, f, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) succeeded")
+	}
+}
@@ -0,0 +1,145 @@
+package project
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// VersionFileName is the per-project version file written by 'vg local'.
+	VersionFileName = ".go-version"
+	// ToolVersionsFileName is the asdf/mise version file.
+	ToolVersionsFileName = ".tool-versions"
+	// GoModFileName is the Go module file.
+	GoModFileName = "go.mod"
+)
+
+// ErrNoVersion is returned when no version file is found.
+var ErrNoVersion = errors.New("no .go-version, .tool-versions or go.mod found")
+
+// FindVersion walks up from dir looking for a project version. In each
+// directory .go-version takes precedence over .tool-versions, which takes
+// precedence over the toolchain (or go) directive in go.mod. It returns the
+// version and the path of the file it was read from.
+func FindVersion(dir string) (string, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	for {
+		for _, read := range []struct {
+			name string
+			fn   func(string) (string, error)
+		}{
+			{VersionFileName, readVersionFile},
+			{ToolVersionsFileName, readToolVersions},
+			{GoModFileName, readGoMod},
+		} {
+			path := filepath.Join(dir, read.name)
+			version, err := read.fn(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return "", "", fmt.Errorf("error reading %s: %w", path, err)
+			}
+			if version != "" {
+				return version, path, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", ErrNoVersion
+		}
+		dir = parent
+	}
+}
+
+// WriteVersionFile writes version to .go-version in dir.
+func WriteVersionFile(dir, version string) (string, error) {
+	path := filepath.Join(dir, VersionFileName)
+	return path, os.WriteFile(path, []byte(version+"\n"), 0644)
+}
+
+// readVersionFile returns the first non-comment line of a .go-version file.
+func readVersionFile(path string) (string, error) {
+	var version string
+	err := scanLines(path, "#", func(line string) bool {
+		version = strings.TrimPrefix(line, "go")
+		return false
+	})
+	return version, err
+}
+
+// readToolVersions returns the golang entry of a .tool-versions file.
+func readToolVersions(path string) (string, error) {
+	var version string
+	err := scanLines(path, "#", func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && (fields[0] == "golang" || fields[0] == "go") {
+			version = strings.TrimPrefix(fields[1], "go")
+			return false
+		}
+		return true
+	})
+	return version, err
+}
+
+// readGoMod returns the toolchain directive of a go.mod file, or the go
+// directive if there is no toolchain line.
+func readGoMod(path string) (string, error) {
+	var goVersion, toolchain string
+	err := scanLines(path, "//", func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return true
+		}
+		switch fields[0] {
+		case "go":
+			goVersion = fields[1]
+		case "toolchain":
+			if fields[1] != "default" {
+				toolchain = strings.TrimPrefix(fields[1], "go")
+			}
+		}
+		return true
+	})
+	if toolchain != "" {
+		return toolchain, err
+	}
+	return goVersion, err
+}
+
+// scanLines calls fn for each trimmed, non-empty line of the file at path,
+// without the comment starting with comment, until fn returns false.
+func scanLines(path, comment string, fn func(string) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, comment); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !fn(line) {
+			break
+		}
+	}
+	return scanner.Err()
+}
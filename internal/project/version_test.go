@@ -0,0 +1,157 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string // paths relative to the root, searched from a/b
+		want  string
+		from  string
+	}{
+		{
+			name:  "go-version",
+			files: map[string]string{"a/b/.go-version": "go1.22.3\n"},
+			want:  "1.22.3",
+			from:  "a/b/.go-version",
+		},
+		{
+			name:  "go-version comments",
+			files: map[string]string{"a/.go-version": "# pinned for CI\n\n1.21.5 # security fix\n"},
+			want:  "1.21.5",
+			from:  "a/.go-version",
+		},
+		{
+			name:  "tool-versions",
+			files: map[string]string{"a/b/.tool-versions": "nodejs 20.1.0\n# golang 1.0\ngolang 1.23.1 # asdf\n"},
+			want:  "1.23.1",
+			from:  "a/b/.tool-versions",
+		},
+		{
+			name:  "tool-versions go entry",
+			files: map[string]string{"a/b/.tool-versions": "go go1.24.0\n"},
+			want:  "1.24.0",
+			from:  "a/b/.tool-versions",
+		},
+		{
+			name:  "go.mod toolchain",
+			files: map[string]string{"a/go.mod": "module example.com/m\n\ngo 1.22\n\ntoolchain go1.23.4 // newer\n"},
+			want:  "1.23.4",
+			from:  "a/go.mod",
+		},
+		{
+			name:  "go.mod go directive",
+			files: map[string]string{"a/go.mod": "module example.com/m\n\n// go 1.10\ngo 1.22.0 // minimum\n"},
+			want:  "1.22.0",
+			from:  "a/go.mod",
+		},
+		{
+			name:  "go.mod toolchain default",
+			files: map[string]string{"a/go.mod": "module example.com/m\ngo 1.21\ntoolchain default\n"},
+			want:  "1.21",
+			from:  "a/go.mod",
+		},
+		{
+			name:  "go.mod keeps #",
+			files: map[string]string{"a/go.mod": "module example.com/m\n\nreplace example.com/x => ./x#y\n\ngo 1.22.1\n"},
+			want:  "1.22.1",
+			from:  "a/go.mod",
+		},
+		{
+			name: "go-version before tool-versions before go.mod",
+			files: map[string]string{
+				"a/b/.go-version":    "1.20.1\n",
+				"a/b/.tool-versions": "golang 1.21.0\n",
+				"a/b/go.mod":         "module m\ngo 1.22.0\n",
+			},
+			want: "1.20.1",
+			from: "a/b/.go-version",
+		},
+		{
+			name: "tool-versions before go.mod",
+			files: map[string]string{
+				"a/b/.tool-versions": "golang 1.21.0\n",
+				"a/b/go.mod":         "module m\ngo 1.22.0\n",
+			},
+			want: "1.21.0",
+			from: "a/b/.tool-versions",
+		},
+		{
+			name: "tool-versions without golang",
+			files: map[string]string{
+				"a/b/.tool-versions": "nodejs 20.1.0\n",
+				"a/b/go.mod":         "module m\ngo 1.22.0\n",
+			},
+			want: "1.22.0",
+			from: "a/b/go.mod",
+		},
+		{
+			name: "nearest directory first",
+			files: map[string]string{
+				"a/.go-version": "1.20.1\n",
+				"a/b/go.mod":    "module m\ngo 1.22.0\n",
+			},
+			want: "1.22.0",
+			from: "a/b/go.mod",
+		},
+		{
+			name: "empty go-version",
+			files: map[string]string{
+				"a/b/.go-version": "# nothing yet\n",
+				"a/.go-version":   "1.23.0\n",
+			},
+			want: "1.23.0",
+			from: "a/.go-version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(root, "a", "b"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			version, path, err := FindVersion(filepath.Join(root, "a", "b"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(root, filepath.FromSlash(tt.from)); version != tt.want || path != want {
+				t.Errorf("FindVersion = %q from %s, want %q from %s", version, path, tt.want, want)
+			}
+		})
+	}
+}
+
+func TestFindVersionNone(t *testing.T) {
+	dir := t.TempDir()
+	// go.mod without a go directive
+	if err := os.WriteFile(filepath.Join(dir, GoModFileName), []byte("module m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Directories above the temporary directory may hold version files, so
+	// only an error other than ErrNoVersion is a failure
+	if _, _, err := FindVersion(dir); err != nil && !errors.Is(err, ErrNoVersion) {
+		t.Errorf("FindVersion = %v", err)
+	}
+}
+
+func TestWriteVersionFile(t *testing.T) {
+	dir := t.TempDir()
+	path, err := WriteVersionFile(dir, "1.24.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, found, err := FindVersion(dir)
+	if err != nil || version != "1.24.0" || found != path {
+		t.Errorf("FindVersion = %q, %s, %v; want 1.24.0 from %s", version, found, err, path)
+	}
+}
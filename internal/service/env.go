@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fun7257/vg/internal/activation"
+	"github.com/fun7257/vg/internal/config"
+)
+
+// NewEnv creates the virtual environment name for version with its own
+// GOPATH, GOCACHE and GOENV, and returns its directory. Callers must hold
+// the activation lock.
+func NewEnv(version, name string) (string, error) {
+	envDir, err := config.GetEnvDir(version, name)
+	if err != nil {
+		return "", fmt.Errorf("error getting env dir: %w", err)
+	}
+	if _, err := os.Stat(envDir); err == nil {
+		return "", &AlreadyExistsError{Version: version, Env: name}
+	}
+
+	// Create env directory structure (envs/<version>/<name>)
+	gopath := filepath.Join(envDir, "gopath")
+	for _, subdir := range []string{"src", "bin", "pkg"} {
+		if err := os.MkdirAll(filepath.Join(gopath, subdir), 0755); err != nil {
+			return "", fmt.Errorf("error creating gopath subdirectory %s: %w", subdir, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(envDir, "gocache"), 0755); err != nil {
+		return "", fmt.Errorf("error creating gocache: %w", err)
+	}
+	goenvContent := fmt.Sprintf("# Environment '%s' (Go %s)\n# Managed by vg.\n", name, version)
+	if err := os.WriteFile(filepath.Join(envDir, "goenv"), []byte(goenvContent), 0644); err != nil {
+		return "", fmt.Errorf("error creating goenv file: %w", err)
+	}
+	return envDir, nil
+}
+
+// envDir returns the directory of an existing virtual environment.
+func envDir(version, name string) (string, error) {
+	envDir, err := config.GetEnvDir(version, name)
+	if err != nil {
+		return "", fmt.Errorf("error getting env dir: %w", err)
+	}
+	if _, err := os.Stat(envDir); os.IsNotExist(err) {
+		return "", &NotInstalledError{Version: version, Env: name}
+	}
+	return envDir, nil
+}
+
+// RemoveEnv deletes a virtual environment of version unless it is active.
+// Callers must hold the activation lock.
+func RemoveEnv(version, name string) error {
+	dir, err := envDir(version, name)
+	if err != nil {
+		return err
+	}
+
+	// The active environment root is the parent of the current GOPATH
+	currentGopathLink, _ := config.GetCurrentGopathLink()
+	targetGopath, _ := os.Readlink(currentGopathLink)
+	absEnvDir, _ := filepath.Abs(dir)
+	absTargetGopath, _ := filepath.Abs(targetGopath)
+	if filepath.Dir(absTargetGopath) == absEnvDir {
+		return &InUseError{Version: version, Env: name}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("error removing environment: %w", err)
+	}
+	return nil
+}
+
+// LoadEnv switches the global GOPATH, GOCACHE and GOENV links to a virtual
+// environment of the active version. Callers must hold the activation lock.
+func LoadEnv(sdksDir, version, name string) error {
+	dir, err := envDir(version, name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(sdksDir, version)); os.IsNotExist(err) {
+		return &NotInstalledError{Version: version}
+	}
+
+	// The 'current' link to the SDK does not change
+	return activation.Switch(activation.Targets{
+		Gopath:  filepath.Join(dir, "gopath"),
+		Gocache: filepath.Join(dir, "gocache"),
+		Goenv:   filepath.Join(dir, "goenv"),
+	})
+}
+
+// ExitEnv switches the global GOPATH, GOCACHE and GOENV links back to the
+// global environment of version. Callers must hold the activation lock.
+func ExitEnv(version string) error {
+	gopath, err := config.GetVersionGopath(version)
+	if err != nil {
+		return fmt.Errorf("error getting global gopath: %w", err)
+	}
+	gocache, err := config.GetVersionGocache(version)
+	if err != nil {
+		return fmt.Errorf("error getting global gocache: %w", err)
+	}
+	goenv, err := config.GetVersionGoenv(version)
+	if err != nil {
+		return fmt.Errorf("error getting global goenv: %w", err)
+	}
+	return activation.Switch(activation.Targets{
+		Gopath:  gopath,
+		Gocache: gocache,
+		Goenv:   goenv,
+	})
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+)
+
+// Exit codes of vg. Wrapper scripts may rely on them; they never change
+// meaning.
+const (
+	ExitOK = 0
+	// ExitFailure is any error without a more specific code.
+	ExitFailure = 1
+	// ExitUsage is an unknown command, flag or invalid arguments.
+	ExitUsage = 2
+	// ExitNotInstalled is a Go version or environment that does not exist.
+	ExitNotInstalled = 3
+	// ExitAlreadyExists is a Go version or environment that already exists.
+	ExitAlreadyExists = 4
+	// ExitInUse is a Go version or environment that is active.
+	ExitInUse = 5
+	// ExitNetwork is a download that failed for network reasons.
+	ExitNetwork = 6
+	// ExitChecksum is an archive not matching its checksum.
+	ExitChecksum = 7
+	// ExitLockTimeout is a lock not acquired within --lock-timeout.
+	ExitLockTimeout = 8
+)
+
+// ErrNoActiveVersion is returned when no Go version has been activated.
+var ErrNoActiveVersion = errors.New("no Go version is currently active")
+
+// NotInstalledError is returned when a Go version, or a virtual environment
+// of it if Env is set, does not exist.
+type NotInstalledError struct {
+	Version string
+	Env     string
+}
+
+func (e *NotInstalledError) Error() string {
+	if e.Env != "" {
+		return fmt.Sprintf("environment '%s' not found for Go %s", e.Env, e.Version)
+	}
+	return fmt.Sprintf("Go %s is not installed", e.Version)
+}
+
+// AlreadyExistsError is returned when a Go version, or a virtual
+// environment of it if Env is set, exists already.
+type AlreadyExistsError struct {
+	Version string
+	Env     string
+}
+
+func (e *AlreadyExistsError) Error() string {
+	if e.Env != "" {
+		return fmt.Sprintf("environment '%s' already exists for Go %s", e.Env, e.Version)
+	}
+	return fmt.Sprintf("Go %s is already installed", e.Version)
+}
+
+// InUseError is returned when an active Go version, or an active virtual
+// environment if Env is set, would be removed.
+type InUseError struct {
+	Version string
+	Env     string
+}
+
+func (e *InUseError) Error() string {
+	if e.Env != "" {
+		return fmt.Sprintf("environment '%s' is currently active", e.Env)
+	}
+	return fmt.Sprintf("Go %s is currently in use", e.Version)
+}
+
+// UsageError is returned for invalid command lines.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ExitError makes vg exit with Code, e.g. the exit code of a command it ran.
+// Nothing is printed if Err is nil.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for err. An error joining several errors
+// gets their common code, or ExitFailure if they differ.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		code := -1
+		for _, e := range joined.Unwrap() {
+			switch c := ExitCode(e); {
+			case code == -1:
+				code = c
+			case code != c:
+				return ExitFailure
+			}
+		}
+		if code != -1 {
+			return code
+		}
+	}
+
+	var (
+		exitErr         *ExitError
+		usageErr        *UsageError
+		notInstalledErr *NotInstalledError
+		existsErr       *AlreadyExistsError
+		inUseErr        *InUseError
+		checksumErr     *downloader.ChecksumError
+		networkErr      *downloader.NetworkError
+		netErr          net.Error
+	)
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	case errors.As(err, &notInstalledErr):
+		return ExitNotInstalled
+	case errors.As(err, &existsErr):
+		return ExitAlreadyExists
+	case errors.As(err, &inUseErr):
+		return ExitInUse
+	case errors.As(err, &checksumErr):
+		return ExitChecksum
+	case errors.Is(err, lock.ErrTimeout):
+		return ExitLockTimeout
+	case errors.As(err, &networkErr), errors.As(err, &netErr):
+		return ExitNetwork
+	}
+	return ExitFailure
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fun7257/vg/internal/downloader"
+	"github.com/fun7257/vg/internal/lock"
+)
+
+func TestExitCode(t *testing.T) {
+	timeout := fmt.Errorf("%w sdk-1.24.0 (held by pid 42: vg install 1.24.0)", lock.ErrTimeout)
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain", errors.New("boom"), ExitFailure},
+		{"usage", &UsageError{Err: errors.New("bad flag")}, ExitUsage},
+		{"not installed", &NotInstalledError{Version: "1.24.0"}, ExitNotInstalled},
+		{"already exists", &AlreadyExistsError{Version: "1.24.0"}, ExitAlreadyExists},
+		{"in use", &InUseError{Version: "1.24.0"}, ExitInUse},
+		{"network", &downloader.NetworkError{Err: errors.New("connection refused")}, ExitNetwork},
+		{"checksum", &downloader.ChecksumError{Path: "go.tar.gz"}, ExitChecksum},
+		{"lock timeout", timeout, ExitLockTimeout},
+		{"wrapped lock timeout", fmt.Errorf("failed to install Go 1.24.0: %w", timeout), ExitLockTimeout},
+		{"exit", &ExitError{Code: 42}, 42},
+		{"joined same", errors.Join(&NotInstalledError{Version: "1"}, &NotInstalledError{Version: "2"}), ExitNotInstalled},
+		{"joined different", errors.Join(&NotInstalledError{Version: "1"}, timeout), ExitFailure},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package service implements the operations behind the vg commands. They
+// report failures as errors, using the types in errors.go where callers
+// need to tell them apart, and leave presentation and locking to the
+// commands.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/fun7257/vg/internal/activation"
+	"github.com/fun7257/vg/internal/config"
+	"github.com/fun7257/vg/internal/downloader"
+)
+
+// goenvContent is written to the GOENV file of a freshly installed version.
+// GOROOT and GOPATH are set by 'vg init', not in this file; users can add
+// custom environment variables or use 'go env -w KEY=VALUE'.
+const goenvContent = "# This file is managed by vg.\n# GOROOT and GOPATH are set automatically by 'vg init'.\n# You can add custom environment variables below or use 'go env -w KEY=VALUE'\n"
+
+// CurrentVersion returns the Go version the global 'current' link points to.
+func CurrentVersion() (string, error) {
+	currentLink, err := config.GetCurrentLink()
+	if err != nil {
+		return "", err
+	}
+	target, err := os.Readlink(currentLink)
+	if err != nil {
+		return "", ErrNoActiveVersion
+	}
+	return filepath.Base(target), nil
+}
+
+// CheckNotInstalled returns an AlreadyExistsError if the SDK name is
+// installed in sdksDir.
+func CheckNotInstalled(sdksDir, name string) error {
+	if downloader.IsInstalled(sdksDir, name) {
+		return &AlreadyExistsError{Version: name}
+	}
+	return nil
+}
+
+// Install downloads and extracts a Go version for installer.Platform and
+// creates its GOPATH, GOENV and GOCACHE, reporting progress through
+// installer. Callers must hold the SDK lock.
+func Install(installer *downloader.Installer, version, distsDir, sdksDir string) error {
+	name := downloader.SDKName(version, installer.Platform)
+	if err := CheckNotInstalled(sdksDir, name); err != nil {
+		return err
+	}
+	// Install handles both downloading (if needed) and extracting
+	// It will skip download if the archive already exists, but will always extract
+	if err := installer.Install(version, distsDir, sdksDir); err != nil {
+		return err
+	}
+	if !installer.Platform.IsNative() {
+		// Nothing runs a foreign SDK here, so it needs no GOPATH or caches
+		fmt.Fprintf(installer.Log, "✅ Installed Go %s for %s as %s\n", version, installer.Platform, name)
+		return nil
+	}
+	return CreateVersionDirs(installer.Log, version)
+}
+
+// CreateVersionDirs creates the GOPATH, GOENV and GOCACHE of a freshly
+// installed version.
+func CreateVersionDirs(log io.Writer, version string) error {
+	gopath, goenvPath, gocache, err := ensureVersionDirs(version, true)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(log, "✅ Created GOPATH: %s\n", gopath)
+	fmt.Fprintf(log, "✅ Created GOENV: %s\n", goenvPath)
+	fmt.Fprintf(log, "✅ Created GOCACHE: %s\n", gocache)
+	return nil
+}
+
+// ensureVersionDirs creates the GOPATH, GOENV and GOCACHE of version where
+// missing, and returns their paths. An existing GOENV file is only reset if
+// resetGoenv is set.
+func ensureVersionDirs(version string, resetGoenv bool) (gopath, goenvPath, gocache string, err error) {
+	gopath, err = config.GetVersionGopath(version)
+	if err != nil {
+		return "", "", "", fmt.Errorf("error getting gopath: %w", err)
+	}
+	// Create standard GOPATH subdirectories
+	for _, subdir := range []string{"src", "bin", "pkg"} {
+		if err := os.MkdirAll(filepath.Join(gopath, subdir), 0755); err != nil {
+			return "", "", "", fmt.Errorf("error creating gopath subdirectory %s: %w", subdir, err)
+		}
+	}
+
+	goenvPath, err = config.GetVersionGoenv(version)
+	if err != nil {
+		return "", "", "", fmt.Errorf("error getting goenv path: %w", err)
+	}
+	if _, err := os.Stat(goenvPath); resetGoenv || os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(goenvPath), 0755); err != nil {
+			return "", "", "", fmt.Errorf("error creating goenvs directory: %w", err)
+		}
+		if err := os.WriteFile(goenvPath, []byte(goenvContent), 0644); err != nil {
+			return "", "", "", fmt.Errorf("error creating goenv file: %w", err)
+		}
+	}
+
+	gocache, err = config.GetVersionGocache(version)
+	if err != nil {
+		return "", "", "", fmt.Errorf("error getting gocache: %w", err)
+	}
+	if err := os.MkdirAll(gocache, 0755); err != nil {
+		return "", "", "", fmt.Errorf("error creating gocache directory: %w", err)
+	}
+	return gopath, goenvPath, gocache, nil
+}
+
+// Use activates an installed version globally by switching the 'current'
+// symlinks to its SDK and global environment. Directories missing for
+// versions installed by older releases of vg are created. Callers must hold
+// the SDK and activation locks.
+func Use(sdksDir, version string) error {
+	if !downloader.IsInstalled(sdksDir, version) {
+		return &NotInstalledError{Version: version}
+	}
+	gopath, goenvPath, gocache, err := ensureVersionDirs(version, false)
+	if err != nil {
+		return err
+	}
+	return activation.Switch(activation.Targets{
+		Goroot:  filepath.Join(sdksDir, version),
+		Gopath:  gopath,
+		Gocache: gocache,
+		Goenv:   goenvPath,
+	})
+}
+
+// CheckRemovable returns a NotInstalledError if version does not exist in
+// sdksDir and an InUseError if it is the active version.
+func CheckRemovable(sdksDir, version string) error {
+	if _, err := os.Stat(filepath.Join(sdksDir, version)); os.IsNotExist(err) {
+		return &NotInstalledError{Version: version}
+	}
+	current, err := CurrentVersion()
+	if err != nil && !errors.Is(err, ErrNoActiveVersion) {
+		return err
+	}
+	if current == version {
+		return &InUseError{Version: version}
+	}
+	return nil
+}
+
+// Remove deletes an installed version together with its GOPATH, GOENV,
+// GOCACHE and virtual environments. Failures to remove anything but the SDK
+// itself are returned as warnings. Callers must hold the SDK and activation
+// locks.
+func Remove(sdksDir, version string) (warnings []error, err error) {
+	if err := CheckRemovable(sdksDir, version); err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(filepath.Join(sdksDir, version)); err != nil {
+		return nil, fmt.Errorf("error removing SDK: %w", err)
+	}
+
+	for _, dir := range []struct {
+		name string
+		path func(string) (string, error)
+	}{
+		{"GOPATH", config.GetVersionGopath},
+		{"GOENV", config.GetVersionGoenv},
+		{"GOCACHE", config.GetVersionGocache},
+		{"Virtual Environments", func(version string) (string, error) {
+			envsRoot, err := config.GetEnvsDir()
+			return filepath.Join(envsRoot, version), err
+		}},
+	} {
+		path, err := dir.path(version)
+		if err != nil {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			warnings = append(warnings, fmt.Errorf("error removing %s: %w", dir.name, err))
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,57 @@
+package shell
+
+import "strings"
+
+type elvish struct{}
+
+func (elvish) Name() string { return "elvish" }
+
+func (elvish) Render(changes []Change) string {
+	return render(changes, func(c Change) string {
+		switch {
+		case c.Unset:
+			return "unset-env " + c.Name
+		case c.Path != nil && c.Prepend:
+			return "set paths = [" + elvishQuoteAll(c.Path) + " $@paths]"
+		case c.Path != nil:
+			return "set paths = [" + elvishQuoteAll(c.Path) + "]"
+		default:
+			return "set-env " + c.Name + " " + elvishQuote(c.Value)
+		}
+	})
+}
+
+func (s elvish) RenderSession(changes []Change) string {
+	return s.Render(changes)
+}
+
+func (elvish) Hook() string {
+	return `fn vg {|@args|
+  var vg-eval = (and (> (count $args) 0) (eq $args[0] shell))
+  if (and (> (count $args) 2) (eq $args[0] env) (has-value [load exit] $args[1])) {
+    for vg-arg $args[2..] {
+      if (eq $vg-arg --) { break }
+      if (eq $vg-arg --session) { set vg-eval = $true }
+    }
+  }
+  if $vg-eval {
+    eval (e:vg $@args | slurp)
+  } else {
+    e:vg $@args
+  }
+}
+`
+}
+
+// elvishQuote single-quotes s; quotes are escaped by doubling them.
+func elvishQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func elvishQuoteAll(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = elvishQuote(s)
+	}
+	return strings.Join(quoted, " ")
+}
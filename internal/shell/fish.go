@@ -0,0 +1,62 @@
+package shell
+
+import "strings"
+
+type fish struct{}
+
+func (fish) Name() string { return "fish" }
+
+func (fish) Render(changes []Change) string {
+	return render(changes, func(c Change) string {
+		switch {
+		case c.Unset:
+			return "set -e " + c.Name
+		case c.Path != nil && c.Prepend:
+			return "set -gx PATH " + fishQuoteAll(c.Path) + " $PATH"
+		case c.Path != nil:
+			return "set -gx PATH " + fishQuoteAll(c.Path)
+		default:
+			return "set -gx " + c.Name + " " + fishQuote(c.Value)
+		}
+	})
+}
+
+func (s fish) RenderSession(changes []Change) string {
+	return s.Render(changes)
+}
+
+func (fish) Hook() string {
+	return `function vg
+    set -l vg_eval 0
+    if test "$argv[1]" = shell
+        set vg_eval 1
+    else if test "$argv[1]" = env; and contains -- "$argv[2]" load exit
+        for vg_arg in $argv[3..-1]
+            test "$vg_arg" = --; and break
+            test "$vg_arg" = --session; and set vg_eval 1
+        end
+    end
+    if test $vg_eval = 1
+        set -l vg_out (command vg $argv)
+        or return $status
+        string join \n $vg_out | source
+    else
+        command vg $argv
+    end
+end
+`
+}
+
+// fishQuote single-quotes s; fish only treats \\ and \' specially inside.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func fishQuoteAll(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = fishQuote(s)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// nushell cannot evaluate generated code at runtime, so session output is a
+// JSON record that the hook passes to load-env.
+type nushell struct{}
+
+func (nushell) Name() string { return "nu" }
+
+func (nushell) Render(changes []Change) string {
+	return render(changes, func(c Change) string {
+		switch {
+		case c.Unset:
+			return "hide-env -i " + c.Name
+		case c.Path != nil && c.Prepend:
+			return "$env.PATH = ($env.PATH | split row (char esep) | prepend " + nuList(c.Path) + ")"
+		case c.Path != nil:
+			return "$env.PATH = " + nuList(c.Path)
+		default:
+			return "$env." + c.Name + " = " + nuString(c.Value)
+		}
+	})
+}
+
+func (nushell) RenderSession(changes []Change) string {
+	record := make(map[string]any, len(changes))
+	for _, c := range changes {
+		switch {
+		case c.Unset:
+			// load-env cannot remove variables; clear them instead
+			record[c.Name] = ""
+		case c.Path != nil:
+			record["PATH"] = c.Path
+		default:
+			record[c.Name] = c.Value
+		}
+	}
+	data, _ := json.Marshal(record)
+	return string(data) + "\n"
+}
+
+func (nushell) Hook() string {
+	return `def --env --wrapped vg [...args] {
+    let vg_flags = ($args | skip 2 | take while {|arg| $arg != "--" })
+    let vg_eval = (($args | first 1) == ["shell"]) or ((($args | first 2 | str join " ") in ["env load" "env exit"]) and ("--session" in $vg_flags))
+    if $vg_eval {
+        let vg_out = (^vg ...$args)
+        if ($vg_out | is-not-empty) {
+            $vg_out | from json | load-env
+        }
+    } else {
+        ^vg ...$args
+    }
+}
+`
+}
+
+// nuString returns s as a double-quoted nushell string. JSON escapes are a
+// subset of nushell's.
+func nuString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func nuList(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = nuString(s)
+	}
+	return "[" + strings.Join(quoted, " ") + "]"
+}
@@ -0,0 +1,63 @@
+package shell
+
+import "strings"
+
+// posix covers bash, zsh and other POSIX-compatible shells.
+type posix struct {
+	name string
+}
+
+func (s posix) Name() string { return s.name }
+
+func (s posix) Render(changes []Change) string {
+	return render(changes, func(c Change) string {
+		switch {
+		case c.Unset:
+			return "unset " + c.Name
+		case c.Path != nil && c.Prepend:
+			return "export PATH=" + posixQuote(strings.Join(c.Path, ":")) + `:"$PATH"`
+		case c.Path != nil:
+			return "export PATH=" + posixQuote(strings.Join(c.Path, ":"))
+		default:
+			return "export " + c.Name + "=" + posixQuote(c.Value)
+		}
+	})
+}
+
+func (s posix) RenderSession(changes []Change) string {
+	return s.Render(changes)
+}
+
+// Hook avoids 'local', which ksh93 lacks: its variables are prefixed globals
+// unset before vg runs, and positional parameters carry values across the
+// unset.
+func (s posix) Hook() string {
+	return `vg() {
+  __vg_eval=0
+  case "$1 $2" in
+    "shell "*) __vg_eval=1 ;;
+    "env load"|"env exit")
+      for __vg_arg in "$@"; do
+        [ "$__vg_arg" = "--" ] && break
+        [ "$__vg_arg" = "--session" ] && __vg_eval=1
+      done
+      ;;
+  esac
+  if [ "$__vg_eval" = 1 ]; then
+    unset __vg_eval __vg_arg
+    __vg_out="$(command vg "$@")" || { set -- "$?"; unset __vg_out; return "$1"; }
+    set -- "$__vg_out"
+    unset __vg_out
+    eval "$1"
+  else
+    unset __vg_eval __vg_arg
+    command vg "$@"
+  fi
+}
+`
+}
+
+// posixQuote single-quotes s.
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
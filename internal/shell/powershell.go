@@ -0,0 +1,62 @@
+package shell
+
+import "strings"
+
+type powershell struct{}
+
+func (powershell) Name() string { return "powershell" }
+
+func (powershell) Render(changes []Change) string {
+	return render(changes, func(c Change) string {
+		switch {
+		case c.Unset:
+			return "Remove-Item -ErrorAction SilentlyContinue Env:" + c.Name
+		case c.Path != nil && c.Prepend:
+			return "$env:PATH = " + psPathList(c.Path) + " + [IO.Path]::PathSeparator + $env:PATH"
+		case c.Path != nil:
+			return "$env:PATH = " + psPathList(c.Path)
+		default:
+			return "$env:" + c.Name + " = " + psQuote(c.Value)
+		}
+	})
+}
+
+func (s powershell) RenderSession(changes []Change) string {
+	return s.Render(changes)
+}
+
+func (powershell) Hook() string {
+	return `function vg {
+    $vgBin = (Get-Command -CommandType Application vg | Select-Object -First 1).Source
+    $vgEval = $args.Count -gt 0 -and $args[0] -eq 'shell'
+    if ($args.Count -gt 2 -and $args[0] -eq 'env' -and $args[1] -in 'load', 'exit') {
+        foreach ($vgArg in $args[2..($args.Count - 1)]) {
+            if ($vgArg -eq '--') { break }
+            if ($vgArg -eq '--session') { $vgEval = $true }
+        }
+    }
+    if ($vgEval) {
+        $vgOut = & $vgBin @args
+        if ($LASTEXITCODE -eq 0 -and $vgOut) {
+            Invoke-Expression ($vgOut -join "` + "`" + `n")
+        }
+    } else {
+        & $vgBin @args
+    }
+}
+`
+}
+
+// psQuote single-quotes s; quotes are escaped by doubling them.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// psPathList joins dirs with the platform path separator.
+func psPathList(dirs []string) string {
+	quoted := make([]string, len(dirs))
+	for i, d := range dirs {
+		quoted[i] = psQuote(d)
+	}
+	return "(" + strings.Join(quoted, ", ") + " -join [IO.Path]::PathSeparator)"
+}
@@ -0,0 +1,136 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnvVar is the environment variable recording the shell vg was initialized
+// for, so that session commands emit code in the right syntax.
+const EnvVar = "VG_SHELL"
+
+// Change is a single environment change rendered by a Shell.
+type Change struct {
+	// Name of the variable. Ignored for PATH changes.
+	Name string
+	// Value of the variable.
+	Value string
+	// Unset removes the variable instead of setting it.
+	Unset bool
+	// Path holds PATH entries. When set, the change replaces PATH, or
+	// prepends to it if Prepend is true.
+	Path    []string
+	Prepend bool
+}
+
+// Set returns a change setting name to value.
+func Set(name, value string) Change {
+	return Change{Name: name, Value: value}
+}
+
+// Unset returns a change removing name.
+func Unset(name string) Change {
+	return Change{Name: name, Unset: true}
+}
+
+// SetPath returns a change replacing PATH with dirs.
+func SetPath(dirs []string) Change {
+	return Change{Name: "PATH", Path: dirs}
+}
+
+// PrependPath returns a change adding dirs in front of the existing PATH.
+func PrependPath(dirs []string) Change {
+	return Change{Name: "PATH", Path: dirs, Prepend: true}
+}
+
+// Shell renders environment changes in the syntax of a particular shell.
+type Shell interface {
+	// Name returns the canonical shell name.
+	Name() string
+	// Render returns code applying changes, as printed by 'vg init'.
+	Render(changes []Change) string
+	// RenderSession returns the output of session commands, which the
+	// function returned by Hook applies to the calling shell.
+	RenderSession(changes []Change) string
+	// Hook returns the definition of the 'vg' wrapper function. It applies
+	// the output of 'vg shell' and of 'vg env load|exit' when --session
+	// comes before any "--"; other output is never evaluated.
+	Hook() string
+}
+
+var shells = map[string]Shell{
+	"bash":       posix{name: "bash"},
+	"zsh":        posix{name: "zsh"},
+	"sh":         posix{name: "sh"},
+	"fish":       fish{},
+	"nu":         nushell{},
+	"powershell": powershell{},
+	"elvish":     elvish{},
+}
+
+// aliases maps alternative names and binary names to canonical names.
+var aliases = map[string]string{
+	"ash":     "sh",
+	"dash":    "sh",
+	"ksh":     "sh",
+	"nushell": "nu",
+	"pwsh":    "powershell",
+}
+
+// Names returns the supported shell names.
+func Names() []string {
+	names := make([]string, 0, len(shells))
+	for name := range shells {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the shell with the given name or alias.
+func Get(name string) (Shell, error) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	if sh, ok := shells[name]; ok {
+		return sh, nil
+	}
+	return nil, fmt.Errorf("unsupported shell %q (supported: %s)", name, strings.Join(Names(), ", "))
+}
+
+// Detect returns the user's login shell as given by $SHELL, defaulting to
+// bash when it is unset. Unknown shells get POSIX syntax, as 'vg init'
+// always printed before other shells were supported.
+func Detect() (Shell, error) {
+	path := os.Getenv("SHELL")
+	if path == "" {
+		return shells["bash"], nil
+	}
+	if sh, err := Get(strings.TrimSuffix(filepath.Base(path), ".exe")); err == nil {
+		return sh, nil
+	}
+	return shells["sh"], nil
+}
+
+// Current returns the shell vg was initialized for ($VG_SHELL), falling back
+// to Detect.
+func Current() (Shell, error) {
+	if name := os.Getenv(EnvVar); name != "" {
+		return Get(name)
+	}
+	return Detect()
+}
+
+// render joins the lines produced by fn for each change.
+func render(changes []Change, fn func(Change) string) string {
+	var b strings.Builder
+	for _, c := range changes {
+		b.WriteString(fn(c))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
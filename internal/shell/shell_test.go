@@ -0,0 +1,167 @@
+package shell
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// initChanges are the changes printed by 'vg init', with values that need
+// quoting.
+var initChanges = []Change{
+	Set(EnvVar, "sh"),
+	Set("GOROOT", "/home/user/.vg/current"),
+	Set("GOPATH", "/home/user/My Projects/it's \"go\"/$HOME"),
+	PrependPath([]string{"/home/user/.vg/current/bin", "/home/user/.vg/current-gopath/bin"}),
+}
+
+// sessionChanges are the changes printed by session commands.
+var sessionChanges = []Change{
+	Set("GOROOT", "/home/user/.vg/sdks/1.24.0"),
+	SetPath([]string{"/home/user/.vg/sdks/1.24.0/bin", "/usr/bin", "/bin"}),
+	Set("VG_SESSION_VERSION", "1.24.0"),
+	Unset("VG_SESSION_ENV"),
+}
+
+func TestGolden(t *testing.T) {
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			sh, err := Get(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			b.WriteString("# Render\n")
+			b.WriteString(sh.Render(initChanges))
+			b.WriteString("# RenderSession\n")
+			b.WriteString(sh.RenderSession(sessionChanges))
+			b.WriteString("# Hook\n")
+			b.WriteString(sh.Hook())
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(b.String()), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got := b.String(); got != string(want) {
+				t.Errorf("output differs from %s (run go test -update after checking the change):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestGetAliases(t *testing.T) {
+	for alias, want := range map[string]string{
+		"ash":     "sh",
+		"dash":    "sh",
+		"ksh":     "sh",
+		"pwsh":    "powershell",
+		"nushell": "nu",
+		"BASH":    "bash",
+	} {
+		sh, err := Get(alias)
+		if err != nil {
+			t.Errorf("Get(%q): %v", alias, err)
+			continue
+		}
+		if sh.Name() != want {
+			t.Errorf("Get(%q) = %s, want %s", alias, sh.Name(), want)
+		}
+	}
+	if _, err := Get("tcsh"); err == nil {
+		t.Error("Get(tcsh) succeeded")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for shellPath, want := range map[string]string{
+		"":                  "bash",
+		"/usr/bin/zsh":      "zsh",
+		"/usr/bin/fish":     "fish",
+		"/bin/ash":          "sh",
+		"/bin/tcsh":         "sh",
+		"/usr/bin/pwsh.exe": "powershell",
+		"/opt/bin/unknown":  "sh",
+	} {
+		t.Setenv("SHELL", filepath.FromSlash(shellPath))
+		sh, err := Detect()
+		if err != nil {
+			t.Errorf("SHELL=%s: %v", shellPath, err)
+			continue
+		}
+		if sh.Name() != want {
+			t.Errorf("SHELL=%s: Detect() = %s, want %s", shellPath, sh.Name(), want)
+		}
+	}
+}
+
+// TestPosixHook runs the hook with a fake vg printing code that marks the
+// shell, to check which commands have their output evaluated.
+func TestPosixHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake vg binary is a shell script")
+	}
+	bin := t.TempDir()
+	fakeVG := "#!/bin/sh\necho 'vg_evaluated=yes'\n[ \"$2\" = fail ] && exit 3\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(bin, "vg"), []byte(fakeVG), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args string
+		eval bool
+	}{
+		{"shell 1.24", true},
+		{"shell", true},
+		{"shell fail", false},
+		{"env load --session myenv", true},
+		{"env load myenv --session", true},
+		{"env exit --session", true},
+		{"env load myenv", false},
+		{"env load myenv -- --session", false},
+		{"exec 1.24 -- tool --session", false},
+		{"use --session", false},
+		{"list", false},
+		{"", false},
+	}
+	for _, name := range []string{"sh", "bash", "ksh"} {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		sh, err := Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			// The hook leaves no variables behind
+			script := sh.Hook() + "vg_evaluated=no\nvg " + tt.args + " >/dev/null || [ $? = 3 ]\n" +
+				"echo $vg_evaluated${__vg_eval+ leaked}${__vg_arg+ leaked}${__vg_out+ leaked}\n"
+			cmd := exec.Command(path, "-c", script)
+			cmd.Env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+			out, err := cmd.Output()
+			if err != nil {
+				t.Errorf("%s: vg %s: %v", name, tt.args, err)
+				continue
+			}
+			want := "no"
+			if tt.eval {
+				want = "yes"
+			}
+			if got := strings.TrimSpace(string(out)); got != want {
+				t.Errorf("%s: vg %s: evaluated = %s, want %s", name, tt.args, got, want)
+			}
+		}
+	}
+}